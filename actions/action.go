@@ -36,6 +36,63 @@ type Actor interface {
 	String() string
 }
 
+// Severity is the severity of a monitor failure.
+type Severity int
+
+// Severities.
+const (
+	SeverityOK Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String returns "ok", "warning", or "critical".
+func (s Severity) String() string {
+	switch s {
+	case SeverityOK:
+		return "ok"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// SeverityActor is an optional interface for actions that
+// distinguish warnings from critical failures.
+type SeverityActor interface {
+	// FailWithSeverity is called instead of Actor.Fail when a probe
+	// starts failing, and is called also when the severity of an
+	// ongoing failure changes.
+	//
+	// Actions that do not implement this receive Actor.Fail when
+	// a probe starts failing with either severity, and are not
+	// notified of severity changes.
+	//
+	// name is the monitor name.
+	// v is the returned value from the probe (or a value from the filter).
+	// s is either SeverityWarning or SeverityCritical.
+	// Non-nil error is logged, but will not stop the monitor.
+	FailWithSeverity(name string, v float64, s Severity) error
+}
+
+// OngoingActor is an optional interface for actions that
+// distinguish reminders of ongoing failures from new failures.
+type OngoingActor interface {
+	// Ongoing is called periodically while a probe keeps failing
+	// if the monitor is configured to re-notify failures.
+	//
+	// name is the monitor name.
+	// v is the returned value from the probe (or a value from the filter).
+	// s is either SeverityWarning or SeverityCritical.
+	// d is the failure duration so far.
+	// Non-nil error is logged, but will not stop the monitor.
+	//
+	// If an action does not implement this, Fail is called instead.
+	Ongoing(name string, v float64, s Severity, d time.Duration) error
+}
+
 // Constructor is a function to create an action.
 //
 // params are configuration options for the action.
@@ -18,11 +18,13 @@ const (
 	eventInit    = "init"
 	eventFail    = "fail"
 	eventRecover = "recover"
+	eventOngoing = "ongoing"
 
 	envMonitor  = "GOMA_MONITOR"
 	envEvent    = "GOMA_EVENT"
 	envValue    = "GOMA_VALUE"
 	envDuration = "GOMA_DURATION"
+	envSeverity = "GOMA_SEVERITY"
 	envVersion  = "GOMA_VERSION"
 )
 
@@ -82,11 +84,28 @@ func (a *action) Init(name string) error {
 }
 
 func (a *action) Fail(name string, v float64) error {
+	return a.FailWithSeverity(name, v, actions.SeverityCritical)
+}
+
+func (a *action) FailWithSeverity(name string, v float64, s actions.Severity) error {
 	env := []string{
 		fmt.Sprintf("%s=%s", envMonitor, name),
 		fmt.Sprintf("%s=%s", envVersion, goma.Version),
 		fmt.Sprintf("%s=%s", envEvent, eventFail),
 		fmt.Sprintf("%s=%g", envValue, v), // %g suppresses trailing zeroes.
+		fmt.Sprintf("%s=%s", envSeverity, s),
+	}
+	return a.run(env)
+}
+
+func (a *action) Ongoing(name string, v float64, s actions.Severity, d time.Duration) error {
+	env := []string{
+		fmt.Sprintf("%s=%s", envMonitor, name),
+		fmt.Sprintf("%s=%s", envVersion, goma.Version),
+		fmt.Sprintf("%s=%s", envEvent, eventOngoing),
+		fmt.Sprintf("%s=%g", envValue, v),
+		fmt.Sprintf("%s=%s", envSeverity, s),
+		fmt.Sprintf("%s=%d", envDuration, int(d.Seconds())),
 	}
 	return a.run(env)
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/actions"
 )
 
 func TestConstruct(t *testing.T) {
@@ -55,6 +56,49 @@ if [ "$GOMA_VALUE" != "0.1" ]; then exit 1; fi
 	}
 }
 
+func TestFailWithSeverity(t *testing.T) {
+	t.Parallel()
+
+	a, err := construct(map[string]interface{}{
+		"command": "sh",
+		"args": []interface{}{"-u", "-c", `
+echo GOMA_SEVERITY=$GOMA_SEVERITY
+if [ "$GOMA_EVENT" != "fail" ]; then exit 1; fi
+if [ "$GOMA_SEVERITY" != "warning" ]; then exit 1; fi
+`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sa := a.(actions.SeverityActor)
+	if err := sa.FailWithSeverity("monitor1", 0.1, actions.SeverityWarning); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOngoing(t *testing.T) {
+	t.Parallel()
+
+	a, err := construct(map[string]interface{}{
+		"command": "sh",
+		"args": []interface{}{"-u", "-c", `
+if [ "$GOMA_EVENT" != "ongoing" ]; then exit 1; fi
+if [ "$GOMA_VALUE" != "0.1" ]; then exit 1; fi
+if [ "$GOMA_SEVERITY" != "critical" ]; then exit 1; fi
+if [ "$GOMA_DURATION" != "600" ]; then exit 1; fi
+`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oa := a.(actions.OngoingActor)
+	if err := oa.Ongoing("monitor1", 0.1, actions.SeverityCritical, 10*time.Minute); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestRecover(t *testing.T) {
 	t.Parallel()
 
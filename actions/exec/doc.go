@@ -5,9 +5,11 @@ Monitor information are passed by environment variables:
 
 	Name           Description
 	GOMA_MOINTOR   The name of the monitor.
-	GOMA_EVENT     Event name.  One of "init", "fail" or "recover".
+	GOMA_EVENT     Event name.  One of "init", "fail", "ongoing" or "recover".
 	GOMA_VALUE     The probe(filter) value.  Available on failure.
-	GOMA_DURATION  Failure duration in seconds.  Available on recovery.
+	GOMA_SEVERITY  "warning" or "critical".  Available on failure.
+	GOMA_DURATION  Failure duration in seconds.  Available on recovery
+	               and ongoing failure.
 	GOMA_VERSION   Goma version such as "0.1".
 
 The constructor takes these parameters:
@@ -19,5 +21,9 @@ The constructor takes these parameters:
 	timeout      int       0        Timeout seconds for command execution.
 	                                Zero disables timeout.
 	debug        bool      false    If true, command outputs are logged on failure.
+
+The command is run with "fail" event also when the severity of an
+ongoing failure changes.  "ongoing" event is a reminder of an ongoing
+failure sent at renotify_interval of the monitor.
 */
 package exec
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -24,8 +25,11 @@ var (
 )
 
 type action struct {
+	client     *http.Client
 	urlInit    *url.URL
 	urlFail    *url.URL
+	urlWarning *url.URL
+	urlOngoing *url.URL
 	urlRecover *url.URL
 	method     string
 	header     map[string]string
@@ -94,7 +98,7 @@ func (a *action) request(u *url.URL, params map[string]string) error {
 		req = req.WithContext(ctx)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := a.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -115,7 +119,15 @@ func (a *action) Init(name string) error {
 }
 
 func (a *action) Fail(name string, v float64) error {
-	if a.urlFail == nil {
+	return a.FailWithSeverity(name, v, actions.SeverityCritical)
+}
+
+func (a *action) FailWithSeverity(name string, v float64, s actions.Severity) error {
+	u := a.urlFail
+	if s == actions.SeverityWarning && a.urlWarning != nil {
+		u = a.urlWarning
+	}
+	if u == nil {
 		return nil
 	}
 	params := make(map[string]string)
@@ -125,7 +137,31 @@ func (a *action) Fail(name string, v float64) error {
 	params["monitor"] = name
 	params["event"] = "fail"
 	params["value"] = fmt.Sprintf("%g", v) // %g suppresses trailing zeroes.
-	return a.request(a.urlFail, params)
+	params["severity"] = s.String()
+	return a.request(u, params)
+}
+
+func (a *action) Ongoing(name string, v float64, s actions.Severity, d time.Duration) error {
+	u := a.urlOngoing
+	if u == nil {
+		u = a.urlFail
+		if s == actions.SeverityWarning && a.urlWarning != nil {
+			u = a.urlWarning
+		}
+	}
+	if u == nil {
+		return nil
+	}
+	params := make(map[string]string)
+	for k, v := range a.params {
+		params[k] = v
+	}
+	params["monitor"] = name
+	params["event"] = "ongoing"
+	params["value"] = fmt.Sprintf("%g", v)
+	params["severity"] = s.String()
+	params["duration"] = strconv.Itoa(int(d.Seconds()))
+	return a.request(u, params)
 }
 
 func (a *action) Recover(name string, d time.Duration) error {
@@ -171,6 +207,30 @@ func construct(params map[string]interface{}) (actions.Actor, error) {
 	default:
 		return nil, err
 	}
+	var uW *url.URL
+	urlWarning, err := goma.GetString("url_warning", params)
+	switch err {
+	case nil:
+		uW, err = url.Parse(urlWarning)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+	var uO *url.URL
+	urlOngoing, err := goma.GetString("url_ongoing", params)
+	switch err {
+	case nil:
+		uO, err = url.Parse(urlOngoing)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
 	urlRecover, err := goma.GetString("url_recover", params)
 	switch err {
 	case nil:
@@ -220,9 +280,28 @@ func construct(params map[string]interface{}) (actions.Actor, error) {
 		return nil, err
 	}
 
+	c := client
+	switch unixSocket, err := goma.GetString("unix_socket", params); err {
+	case nil:
+		c = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", unixSocket)
+				},
+			},
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
 	return &action{
+		client:     c,
 		urlInit:    uI,
 		urlFail:    uF,
+		urlWarning: uW,
+		urlOngoing: uO,
 		urlRecover: uR,
 		method:     method,
 		header:     header,
@@ -9,12 +9,14 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/actions"
 )
 
 const (
@@ -65,6 +67,17 @@ func serve(l net.Listener) {
 			return
 		}
 	})
+	router.HandleFunc("/warning", func(w http.ResponseWriter, r *http.Request) {
+		if err := checkRequest(r, http.MethodGet, "fail"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("severity") != "warning" {
+			http.Error(w, `r.FormValue("severity") != "warning"`,
+				http.StatusBadRequest)
+			return
+		}
+	})
 	router.HandleFunc("/recover", func(w http.ResponseWriter, r *http.Request) {
 		if err := checkRequest(r, http.MethodGet, "recover"); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -180,6 +193,26 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+func TestWarning(t *testing.T) {
+	t.Parallel()
+
+	a, err := construct(map[string]interface{}{
+		"url_fail":    makeURL("500"),
+		"url_warning": makeURL("warning"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sa := a.(actions.SeverityActor)
+	if err := sa.FailWithSeverity("monitor1", 0.2, actions.SeverityWarning); err != nil {
+		t.Error(err)
+	}
+	if err := sa.FailWithSeverity("monitor1", 0.2, actions.SeverityCritical); err == nil {
+		t.Error("critical failures should be sent to url_fail")
+	}
+}
+
 func TestError(t *testing.T) {
 	t.Parallel()
 
@@ -278,3 +311,38 @@ func TestTimeout(t *testing.T) {
 		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
 	}
 }
+
+func TestUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "goma.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Host != "goma.local" || r.URL.Path != "/init" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if err := checkRequest(r, http.MethodGet, "init"); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		}),
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	a, err := construct(map[string]interface{}{
+		"url_init":    "http://goma.local/init",
+		"unix_socket": sock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Init("monitor1"); err != nil {
+		t.Error(err)
+	}
+}
@@ -6,9 +6,11 @@ GET or POST form variables are automatically appended as follows:
 	Name           Description
 	monitor        The monitor name.
 	host           Hostname where goma server is running.
-	event          One of "init", "fail", or "recover".
+	event          One of "init", "fail", "ongoing", or "recover".
 	value          The probe(filter) value.  Appended on failure.
-	duration       Failure duration in seconds.  Appended on recovery.
+	severity       "warning" or "critical".  Appended on failure.
+	duration       Failure duration in seconds.  Appended on recovery
+	               and ongoing failure.
 	version        Goma version such as "0.1".
 
 The constructor takes these parameters:
@@ -16,6 +18,8 @@ The constructor takes these parameters:
 	Name         Type               Default  Description
 	url_init     string                      URL to access on monitor startup.  Optional.
 	url_fail     string                      URL to access on monitor failure.  Optional.
+	url_warning  string             url_fail URL to access on warnings.  Optional.
+	url_ongoing  string             (*)      URL to access on ongoing failure.  Optional.
 	url_recover  string                      URL to access on monitor recovery.  Optional.
 	method       string             GET      HTTP method to use.
 	agent        string             goma/0.1 User-Agent string.
@@ -23,9 +27,21 @@ The constructor takes these parameters:
 	params       map[string]string  nil      Additional form parameters.
 	timeout      int                30       Timeout seconds for requests.
 	                                         Zero means the default timeout.
+	unix_socket  string                      Path of Unix domain socket.  Optional.
 
 If URL is not given for an event type, no request is sent for the event.
 
+"fail" event is sent also when the severity of an ongoing failure changes.
+Failures with "warning" severity are sent to url_warning if given.
+
+"ongoing" event is a reminder of an ongoing failure sent at
+renotify_interval of the monitor.  (*) If url_ongoing is not given,
+the event is sent to the URL for "fail" event.
+
+If unix_socket is given, requests are sent to the socket instead of
+the host in URLs.  The URLs are still used for the path and Host header.
+Proxy is not used in this case.
+
 Proxy can be specified through environment variables.
 See net.http.ProxyFromEnvironment for details.
 
@@ -27,6 +27,7 @@ Host: {{ .Host }}
 Date: {{ .Date }}
 Event: {{ .Event }}
 Value: {{printf "%g" .Value}}
+Severity: {{ .Severity }}
 Duration: {{ .Duration }}
 Version: {{ .Version }}
 `
@@ -39,6 +40,7 @@ type tplParams struct {
 	Date     time.Time
 	Event    string
 	Value    float64
+	Severity string
 	Duration int
 	Version  string
 }
@@ -55,6 +57,8 @@ type action struct {
 	to        []*mail.Address
 	initTo    []*mail.Address
 	failTo    []*mail.Address
+	warningTo []*mail.Address
+	ongoingTo []*mail.Address
 	recoverTo []*mail.Address
 	subject   *template.Template
 	body      *template.Template
@@ -132,12 +136,39 @@ func (a *action) Init(name string) error {
 }
 
 func (a *action) Fail(name string, v float64) error {
+	return a.FailWithSeverity(name, v, actions.SeverityCritical)
+}
+
+func (a *action) failAddresses(s actions.Severity) []*mail.Address {
+	if s == actions.SeverityWarning && a.warningTo != nil {
+		return a.warningTo
+	}
+	return a.failTo
+}
+
+func (a *action) FailWithSeverity(name string, v float64, s actions.Severity) error {
 	params := &tplParams{
-		Monitor: name,
-		Event:   "fail",
-		Value:   v,
+		Monitor:  name,
+		Event:    "fail",
+		Value:    v,
+		Severity: s.String(),
+	}
+	return a.send(params, a.failAddresses(s))
+}
+
+func (a *action) Ongoing(name string, v float64, s actions.Severity, d time.Duration) error {
+	params := &tplParams{
+		Monitor:  name,
+		Event:    "ongoing",
+		Value:    v,
+		Severity: s.String(),
+		Duration: int(d.Seconds()),
 	}
-	return a.send(params, a.failTo)
+	altTo := a.ongoingTo
+	if altTo == nil {
+		altTo = a.failAddresses(s)
+	}
+	return a.send(params, altTo)
 }
 
 func (a *action) Recover(name string, d time.Duration) error {
@@ -195,6 +226,14 @@ func construct(params map[string]interface{}) (actions.Actor, error) {
 	if err != nil {
 		return nil, err
 	}
+	warningTo, err := getAddressList("warning_to", params)
+	if err != nil {
+		return nil, err
+	}
+	ongoingTo, err := getAddressList("ongoing_to", params)
+	if err != nil {
+		return nil, err
+	}
 	recoverTo, err := getAddressList("recover_to", params)
 	if err != nil {
 		return nil, err
@@ -275,6 +314,8 @@ func construct(params map[string]interface{}) (actions.Actor, error) {
 		to:        to,
 		initTo:    initTo,
 		failTo:    failTo,
+		warningTo: warningTo,
+		ongoingTo: ongoingTo,
 		recoverTo: recoverTo,
 		subject:   subject,
 		body:      body,
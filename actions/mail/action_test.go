@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/actions"
 )
 
 const (
@@ -275,6 +276,91 @@ func TestFailMail(t *testing.T) {
 	}
 }
 
+func TestWarningMail(t *testing.T) {
+	a, err := construct(map[string]interface{}{
+		"from": "Hirotaka Yamamoto <ymmt@example.org>",
+		"fail_to": []interface{}{
+			"kazu@example.org",
+		},
+		"warning_to": []interface{}{
+			"warn1@example.org",
+			"warn2@example.org",
+		},
+		"server": testAddress,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = a.(actions.SeverityActor).FailWithSeverity("monitor1", 12.5, actions.SeverityWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := <-chServer
+	if len(data.to) != 2 {
+		t.Error(`len(data.to) != 2`)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(data.data))
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(body, []byte("Severity: warning")) {
+		t.Error(`!bytes.Contains(body, []byte("Severity: warning"))`)
+	}
+
+	err = a.Fail("monitor1", 123.45)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = <-chServer
+	if len(data.to) != 1 {
+		t.Error(`len(data.to) != 1`)
+	}
+}
+
+func TestOngoingMail(t *testing.T) {
+	a, err := construct(map[string]interface{}{
+		"from": "Hirotaka Yamamoto <ymmt@example.org>",
+		"fail_to": []interface{}{
+			"kazu@example.org",
+		},
+		"server": testAddress,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = a.(actions.OngoingActor).Ongoing("monitor1", 12.5, actions.SeverityCritical, 3*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := <-chServer
+	if len(data.to) != 1 {
+		t.Error(`len(data.to) != 1`)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(data.data))
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Contains(body, []byte("Event: ongoing")) {
+		t.Error(`!bytes.Contains(body, []byte("Event: ongoing"))`)
+	}
+	if !bytes.Contains(body, []byte("Duration: 180")) {
+		t.Error(`!bytes.Contains(body, []byte("Duration: 180"))`)
+	}
+}
+
 func TestRecoverMail(t *testing.T) {
 	a, err := construct(map[string]interface{}{
 		"from": "Hirotaka Yamamoto <ymmt@example.org>",
@@ -10,9 +10,11 @@ The template is rendered with this struct:
 	    Monitor   string    // The monitor name.
 	    Host      string    // The hostname where goma server is running.
 	    Time      time.Time // The time of the event.
-	    Event     string    // One of "init", "fail", or "recover".
+	    Event     string    // One of "init", "fail", "ongoing", or "recover".
 	    Value     float64   // The probe(filter) value.  Set on failure.
-	    Duration  int       // Failure duration in seconds.  Set on recovery.
+	    Severity  string    // "warning" or "critical".  Set on failure.
+	    Duration  int       // Failure duration in seconds.  Set on recovery
+	                        // and ongoing failure.
 	    Version   string    // Goma version such as "0.1".
 	}
 
@@ -23,6 +25,8 @@ The constructor takes these parameters:
 	to          []string           nil           Destination mail addresses.
 	init_to     []string           nil           Addresses for "init".
 	fail_to     []string           nil           Addresses for "fail".
+	warning_to  []string           fail_to       Addresses for "fail" with "warning" severity.
+	ongoing_to  []string           (*)           Addresses for "ongoing".
 	recover_to  []string           nil           Addresses for "recover".
 	subject     string             (See source)  Subject template.
 	body        string             (See source)  Mail body template.
@@ -35,6 +39,13 @@ The constructor takes these parameters:
 If no destination address is given for an event, mail is not sent.
 For example, mail is not sent on "init" event if both to and init_to are nil.
 
+Mails for "fail" event are sent also when the severity of an ongoing
+failure changes.
+
+"ongoing" event is a reminder of an ongoing failure sent at
+renotify_interval of the monitor.  (*) If ongoing_to is not given,
+addresses for "fail" event are used.
+
 Extra headers must begin with "X-" for security reasons.
 */
 package mail
@@ -8,7 +8,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cybozu-go/goma"
 	"github.com/gorilla/mux"
@@ -61,10 +63,10 @@ func cmdList(r *mux.Router, args []string) error {
 		return err
 	}
 
-	fmt.Printf("%-8s  %-32s  Running  Failing\n", "ID", "Name")
+	fmt.Printf("%-8s  %-32s  Running  Failing  Severity\n", "ID", "Name")
 	for _, i := range l {
-		fmt.Printf("%-8d  %-32s  %-7v  %v\n",
-			i.ID, i.Name, i.Running, i.Failing)
+		fmt.Printf("%-8d  %-32s  %-7v  %-7v  %s\n",
+			i.ID, i.Name, i.Running, i.Failing, i.Severity)
 	}
 	return nil
 }
@@ -108,6 +110,27 @@ func cmdRegister(r *mux.Router, args []string) error {
 	return nil
 }
 
+func cmdReload(r *mux.Router, args []string) error {
+	if len(args) != 0 {
+		return errors.New("wrong number of arguments")
+	}
+	client := &http.Client{}
+	url, err := r.Get("reload").URL()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(newRequest(http.MethodPost, url.Path, nil))
+	if err != nil {
+		return err
+	}
+	_, err = readResponse(resp)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Reloaded.")
+	return nil
+}
+
 func cmdShow(r *mux.Router, args []string) error {
 	if len(args) != 1 {
 		return errors.New("wrong number of arguments")
@@ -135,6 +158,43 @@ func cmdShow(r *mux.Router, args []string) error {
 	fmt.Println("Name:", info.Name)
 	fmt.Printf("Running: %v\n", info.Running)
 	fmt.Printf("Failing: %v\n", info.Failing)
+	fmt.Printf("Severity: %s\n", info.Severity)
+	fmt.Printf("Silenced: %v\n", info.Silenced)
+	fmt.Printf("FailAfter: %d\n", info.FailAfter)
+	fmt.Printf("RecoverAfter: %d\n", info.RecoverAfter)
+	return nil
+}
+
+func cmdHistory(r *mux.Router, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments")
+	}
+	client := &http.Client{}
+	url, err := r.Get("history").URL("id", args[0])
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(newRequest(http.MethodGet, url.Path, nil))
+	if err != nil {
+		return err
+	}
+
+	data, err := readResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	var h goma.History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return err
+	}
+
+	fmt.Printf("%-25s  %-14s  %-14s  Duration\n", "Time", "Value", "Filtered")
+	for _, e := range h {
+		fmt.Printf("%-25s  %-14g  %-14g  %.3fs\n",
+			e.Time.Format(time.RFC3339), e.Value, e.Filtered, e.Duration)
+	}
 	return nil
 }
 
@@ -205,6 +265,100 @@ func cmdUnregister(r *mux.Router, args []string) error {
 	return nil
 }
 
+func cmdSilence(r *mux.Router, args []string) error {
+	client := &http.Client{}
+	url, err := r.Get("silences").URL()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		resp, err := client.Do(newRequest(http.MethodGet, url.Path, nil))
+		if err != nil {
+			return err
+		}
+		data, err := readResponse(resp)
+		if err != nil {
+			return err
+		}
+
+		var l goma.Silences
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+
+		fmt.Printf("%-8s  %-24s  %-25s  %-25s  Comment\n", "ID", "Target", "Start", "End")
+		for _, s := range l {
+			target := s.Pattern
+			if s.MonitorID != nil {
+				target = fmt.Sprintf("id=%d", *s.MonitorID)
+			}
+			fmt.Printf("%-8d  %-24s  %-25s  %-25s  %s\n", s.ID, target,
+				s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Comment)
+		}
+		return nil
+	}
+
+	if len(args) < 2 {
+		return errors.New("wrong number of arguments")
+	}
+	d, err := time.ParseDuration(args[1])
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	si := &goma.SilenceInfo{
+		Start:   now,
+		End:     now.Add(d),
+		Comment: strings.Join(args[2:], " "),
+	}
+	if id, err := strconv.Atoi(args[0]); err == nil {
+		si.MonitorID = &id
+	} else {
+		si.Pattern = args[0]
+	}
+
+	data, err := json.Marshal(si)
+	if err != nil {
+		return err
+	}
+	req := newRequest(http.MethodPost, url.Path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	data, err = readResponse(resp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Silenced until %s as silence id=%s\n",
+		si.End.Format(time.RFC3339), string(data))
+	return nil
+}
+
+func cmdUnsilence(r *mux.Router, args []string) error {
+	if len(args) != 1 {
+		return errors.New("wrong number of arguments")
+	}
+	client := &http.Client{}
+	url, err := r.Get("silence").URL("id", args[0])
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(newRequest(http.MethodDelete, url.Path, nil))
+	if err != nil {
+		return err
+	}
+	_, err = readResponse(resp)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Unsilenced.")
+	return nil
+}
+
 func cmdVerbosity(r *mux.Router, args []string) error {
 	client := &http.Client{}
 	url, err := r.Get("verbosity").URL()
@@ -245,10 +399,14 @@ func runCommand(cmd string, args []string) error {
 	commands := map[string]func(r *mux.Router, args []string) error{
 		"list":       cmdList,
 		"register":   cmdRegister,
+		"reload":     cmdReload,
 		"show":       cmdShow,
+		"history":    cmdHistory,
 		"start":      cmdStart,
 		"stop":       cmdStop,
 		"unregister": cmdUnregister,
+		"silence":    cmdSilence,
+		"unsilence":  cmdUnsilence,
 		"verbosity":  cmdVerbosity,
 	}
 	if f, ok := commands[cmd]; ok {
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 	"github.com/cybozu-go/goma"
 	"github.com/cybozu-go/goma/monitor"
+	"github.com/cybozu-go/log"
 )
 
 func loadTOML(f string) ([]*goma.MonitorDefinition, error) {
@@ -32,39 +35,137 @@ func loadTOML(f string) ([]*goma.MonitorDefinition, error) {
 	return s.Monitors, nil
 }
 
-func loadFile(f string) error {
-	defs, err := loadTOML(f)
+// configMonitor is a monitor defined in a configuration file.
+type configMonitor struct {
+	def *goma.MonitorDefinition
+	m   *monitor.Monitor
+}
+
+var (
+	configLock sync.Mutex
+
+	// monitors loaded from configuration files indexed by configKey.
+	configMonitors = make(map[string]*configMonitor)
+)
+
+// configKey identifies a monitor definition in configuration files.
+// n distinguishes monitors having the same name in a file.
+func configKey(f, name string, n int) string {
+	return fmt.Sprintf("%s\x00%s\x00%d", f, name, n)
+}
+
+func loadConfigs(dir string) error {
+	// restore monitors registered via REST API first to keep their IDs.
+	if err := goma.LoadState(); err != nil {
+		return err
+	}
+
+	return reloadConfigs(dir)
+}
+
+// reloadConfigs applies configuration files in dir to running monitors.
+//
+// New monitors are registered and started, removed monitors are stopped
+// and unregistered, and changed monitors are replaced with the same ID.
+// Replaced monitors are started only if the old ones were running.
+// Monitors unregistered via REST API are not re-created until restart.
+// Other monitors are left untouched.
+//
+// If any file has an error, no monitors are changed.
+func reloadConfigs(dir string) error {
+	configLock.Lock()
+	defer configLock.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.toml"))
 	if err != nil {
 		return err
 	}
 
-	monitors := make([]*monitor.Monitor, 0, len(defs))
-	for _, md := range defs {
+	var keys []string
+	defs := make(map[string]*goma.MonitorDefinition)
+	for _, f := range files {
+		l, err := loadTOML(f)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f, err)
+		}
+		counts := make(map[string]int)
+		for _, md := range l {
+			key := configKey(f, md.Name, counts[md.Name])
+			counts[md.Name]++
+			keys = append(keys, key)
+			defs[key] = md
+		}
+	}
+
+	// create monitors before changing anything.
+	created := make(map[string]*monitor.Monitor)
+	for _, key := range keys {
+		md := defs[key]
+		if cm, ok := configMonitors[key]; ok {
+			if monitor.FindMonitor(cm.m.ID()) != cm.m {
+				// unregistered via REST API.
+				continue
+			}
+			if reflect.DeepEqual(cm.def, md) {
+				continue
+			}
+		}
 		m, err := goma.CreateMonitor(md)
 		if err != nil {
+			for _, m := range created {
+				m.Close()
+			}
 			return err
 		}
-		monitors = append(monitors, m)
+		created[key] = m
 	}
 
-	for _, m := range monitors {
-		// ignoring errors is safe at this point.
-		monitor.Register(m)
-		m.Start()
+	for key, cm := range configMonitors {
+		if _, ok := defs[key]; ok {
+			continue
+		}
+		delete(configMonitors, key)
+		if monitor.FindMonitor(cm.m.ID()) != cm.m {
+			continue
+		}
+		cm.m.Stop()
+		monitor.Unregister(cm.m)
+		cm.m.Close()
+		log.Info("removed monitor", map[string]interface{}{
+			"name": cm.def.Name,
+		})
 	}
-	return nil
-}
 
-func loadConfigs(dir string) error {
-	files, err := filepath.Glob(filepath.Join(dir, "*.toml"))
-	if err != nil {
-		return err
-	}
+	for _, key := range keys {
+		m, ok := created[key]
+		if !ok {
+			continue
+		}
 
-	for _, f := range files {
-		if err := loadFile(f); err != nil {
-			return err
+		// ignoring errors is safe at this point.
+		running := true
+		if cm, ok := configMonitors[key]; ok {
+			id := cm.m.ID()
+			running = cm.m.Running()
+			cm.m.Stop()
+			monitor.Unregister(cm.m)
+			cm.m.Close()
+			monitor.RegisterWithID(m, id)
+			log.Info("changed monitor", map[string]interface{}{
+				"monitor_id": m.ID(),
+				"name":       m.Name(),
+			})
+		} else {
+			monitor.Register(m)
+			log.Info("new monitor", map[string]interface{}{
+				"monitor_id": m.ID(),
+				"name":       m.Name(),
+			})
+		}
+		if running {
+			m.Start()
 		}
+		configMonitors[key] = &configMonitor{def: defs[key], m: m}
 	}
 	return nil
 }
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+
+	"github.com/cybozu-go/goma/monitor"
+	"github.com/cybozu-go/goma/probes"
 )
 
 const content = `
@@ -42,3 +48,280 @@ func TestLoadTOML(t *testing.T) {
 		t.Fatalf("monitor[0].filter.window is not int64: %T", v)
 	}
 }
+
+const reloadConfig1 = `
+[[monitor]]
+name = "monitor1"
+interval = 3600
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+
+[[monitor]]
+name = "monitor2"
+interval = 3600
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+`
+
+const reloadConfig2 = `
+[[monitor]]
+name = "monitor2"
+interval = 3600
+max = 1.0
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+
+[[monitor]]
+name = "monitor3"
+interval = 3600
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+`
+
+const reloadConfig3 = `
+[[monitor]]
+name = "monitor2"
+interval = 3600
+max = 2.0
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+
+[[monitor]]
+name = "monitor3"
+interval = 3600
+max = 1.0
+[monitor.probe]
+type = "closetest"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+
+[[monitor]]
+name = "monitor4"
+interval = 3600
+[monitor.probe]
+type = "closetest"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+`
+
+const reloadConfig4 = `
+[[monitor]]
+name = "monitor2"
+interval = 3600
+max = 2.0
+[monitor.probe]
+type = "exec"
+command = "true"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+`
+
+const reloadConfig5 = `
+[[monitor]]
+name = "monitor5"
+interval = 3600
+[monitor.probe]
+type = "closetest"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+
+[[monitor]]
+name = "monitor6"
+interval = 3600
+[monitor.probe]
+type = "no-such-probe"
+[[monitor.actions]]
+type = "exec"
+command = "true"
+`
+
+var closedProbes int32
+
+// closeProbe counts calls of Close.
+type closeProbe struct{}
+
+func (p closeProbe) Probe(ctx context.Context) float64 {
+	return 0
+}
+
+func (p closeProbe) String() string {
+	return "probe:closetest"
+}
+
+func (p closeProbe) Close() error {
+	atomic.AddInt32(&closedProbes, 1)
+	return nil
+}
+
+func init() {
+	probes.Register("closetest", func(map[string]interface{}) (probes.Prober, error) {
+		return closeProbe{}, nil
+	})
+}
+
+func findMonitorByName(name string) *monitor.Monitor {
+	for _, m := range monitor.ListMonitors() {
+		if m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestReloadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "test.toml")
+	if err := os.WriteFile(f, []byte(reloadConfig1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, m := range monitor.ListMonitors() {
+			m.Stop()
+			monitor.Unregister(m)
+		}
+	}()
+
+	m1 := findMonitorByName("monitor1")
+	m2 := findMonitorByName("monitor2")
+	if m1 == nil || m2 == nil {
+		t.Fatal("monitors are not registered")
+	}
+	id2 := m2.ID()
+
+	// unchanged files change nothing.
+	if err := reloadConfigs(dir); err != nil {
+		t.Fatal(err)
+	}
+	if findMonitorByName("monitor1") != m1 {
+		t.Error("monitor1 should be untouched")
+	}
+
+	// broken files change nothing.
+	if err := os.WriteFile(filepath.Join(dir, "bad.toml"), []byte("[[monitor]]\nfoo = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err == nil {
+		t.Error("reload should fail")
+	}
+	if findMonitorByName("monitor1") != m1 || findMonitorByName("monitor2") != m2 {
+		t.Error("monitors should be untouched")
+	}
+	os.Remove(filepath.Join(dir, "bad.toml"))
+
+	if err := os.WriteFile(f, []byte(reloadConfig2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err != nil {
+		t.Fatal(err)
+	}
+	if findMonitorByName("monitor1") != nil {
+		t.Error("monitor1 should be removed")
+	}
+	if m1.Running() {
+		t.Error("monitor1 should be stopped")
+	}
+	nm2 := findMonitorByName("monitor2")
+	if nm2 == nil || nm2 == m2 {
+		t.Fatal("monitor2 should be replaced")
+	}
+	if nm2.ID() != id2 {
+		t.Error("monitor2 should keep its ID")
+	}
+	if !nm2.Running() {
+		t.Error("monitor2 should be running")
+	}
+	if m2.Running() {
+		t.Error("old monitor2 should be stopped")
+	}
+	if findMonitorByName("monitor3") == nil {
+		t.Error("monitor3 should be registered")
+	}
+
+	// stopped monitors are kept stopped, and monitors unregistered
+	// via REST API are not re-created.
+	m3 := findMonitorByName("monitor3")
+	id3 := m3.ID()
+	m3.Stop()
+	nm2.Stop()
+	monitor.Unregister(nm2)
+	if err := os.WriteFile(f, []byte(reloadConfig3), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err != nil {
+		t.Fatal(err)
+	}
+	if findMonitorByName("monitor2") != nil {
+		t.Error("monitor2 should not be re-created")
+	}
+	nm3 := findMonitorByName("monitor3")
+	if nm3 == nil || nm3 == m3 {
+		t.Fatal("monitor3 should be replaced")
+	}
+	if nm3.ID() != id3 {
+		t.Error("monitor3 should keep its ID")
+	}
+	if nm3.Running() {
+		t.Error("monitor3 should be kept stopped")
+	}
+	if m4 := findMonitorByName("monitor4"); m4 == nil || !m4.Running() {
+		t.Error("monitor4 should be running")
+	}
+
+	// probes of removed monitors are closed.
+	if err := os.WriteFile(f, []byte(reloadConfig4), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err != nil {
+		t.Fatal(err)
+	}
+	if findMonitorByName("monitor3") != nil || findMonitorByName("monitor4") != nil {
+		t.Error("monitor3 and monitor4 should be removed")
+	}
+	if n := atomic.LoadInt32(&closedProbes); n != 2 {
+		t.Error("probes are not closed:", n)
+	}
+	if findMonitorByName("monitor2") != nil {
+		t.Error("monitor2 should not be re-created")
+	}
+
+	// probes created before a failure are closed.
+	if err := os.WriteFile(f, []byte(reloadConfig5), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadConfigs(dir); err == nil {
+		t.Error("reload should fail")
+	}
+	if findMonitorByName("monitor5") != nil {
+		t.Error("monitor5 should not be registered")
+	}
+	if n := atomic.LoadInt32(&closedProbes); n != 3 {
+		t.Error("probes are not closed:", n)
+	}
+}
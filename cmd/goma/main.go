@@ -23,6 +23,7 @@ const (
 var (
 	confDir    = flag.String("d", defaultConfDir, "directory for monitor configs")
 	listenAddr = flag.String("s", defaultListenAddr, "HTTP server address")
+	stateDir   = flag.String("state", "", "directory to save monitors registered via REST API")
 )
 
 func usage() {
@@ -40,10 +41,17 @@ Commands:
     list               List registered monitors.
     register FILE      Register monitors defined in FILE.
                        If FILE is "-", goma reads from stdin.
+    reload             Reload monitor configs of the agent.
     show ID            Show the status of a monitor for ID.
+    history ID         Show recent probe results of a monitor for ID.
     start ID           Start a monitor.
     stop ID            Stop a monitor.
     unregister ID      Stop and unregister a monitor.
+    silence [TARGET DURATION [COMMENT...]]
+                       Suppress actions of monitors for DURATION such as
+                       "2h30m".  TARGET is a monitor ID or a name pattern.
+                       Without arguments, goma lists silences.
+    unsilence ID       Remove a silence.
     verbosity [LEVEL]  Query or change logging threshold.
 `)
 }
@@ -71,10 +79,18 @@ func main() {
 		return
 	}
 
+	if err := goma.SetStateDir(*stateDir); err != nil {
+		log.ErrorExit(err)
+	}
 	if err := loadConfigs(*confDir); err != nil {
 		log.ErrorExit(err)
 	}
 
+	goma.SetReloader(func() error {
+		return reloadConfigs(*confDir)
+	})
+	handleSIGHUP(*confDir)
+
 	goma.Serve(*listenAddr)
 	err := well.Wait()
 	if err != nil && !well.IsSignaled(err) {
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cybozu-go/log"
+	"github.com/cybozu-go/well"
+)
+
+// handleSIGHUP reloads configuration files in dir upon SIGHUP.
+func handleSIGHUP(dir string) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	well.Go(func(ctx context.Context) error {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ch:
+			}
+
+			if err := reloadConfigs(dir); err != nil {
+				log.Error("failed to reload", map[string]interface{}{
+					"error": err.Error(),
+				})
+				continue
+			}
+			log.Info("reloaded", nil)
+		}
+	})
+}
@@ -19,11 +19,14 @@ const (
 
 // Errors for goma.
 var (
-	ErrBadName      = errors.New("bad monitor name")
-	ErrNoType       = errors.New("no type")
-	ErrInvalidType  = errors.New("invalid type")
-	ErrInvalidRange = errors.New("invalid min/max range")
-	ErrNoKey        = errors.New("no key")
+	ErrBadName         = errors.New("bad monitor name")
+	ErrNoType          = errors.New("no type")
+	ErrInvalidType     = errors.New("invalid type")
+	ErrInvalidRange    = errors.New("invalid min/max range")
+	ErrInvalidCount    = errors.New("invalid fail_after/recover_after")
+	ErrInvalidWarn     = errors.New("invalid warn_min/warn_max range")
+	ErrInvalidRenotify = errors.New("invalid renotify_interval")
+	ErrNoKey           = errors.New("no key")
 )
 
 // MonitorDefinition is a struct to load monitor definitions.
@@ -37,6 +40,14 @@ type MonitorDefinition struct {
 	Timeout  int                      `toml:"timeout" json:"timeout,omitempty"`
 	Min      float64                  `toml:"min" json:"min,omitempty"`
 	Max      float64                  `toml:"max" json:"max,omitempty"`
+
+	FailAfter    int `toml:"fail_after" json:"fail_after,omitempty"`
+	RecoverAfter int `toml:"recover_after" json:"recover_after,omitempty"`
+
+	WarnMin *float64 `toml:"warn_min" json:"warn_min,omitempty"`
+	WarnMax *float64 `toml:"warn_max" json:"warn_max,omitempty"`
+
+	RenotifyInterval int `toml:"renotify_interval" json:"renotify_interval,omitempty"`
 }
 
 func getType(m map[string]interface{}) (t string, err error) {
@@ -120,6 +131,31 @@ func CreateMonitor(d *MonitorDefinition) (*monitor.Monitor, error) {
 		return nil, ErrInvalidRange
 	}
 
-	return monitor.NewMonitor(d.Name, probe, filter, actors,
-		interval, timeout, d.Min, d.Max), nil
+	if d.FailAfter < 0 || d.RecoverAfter < 0 {
+		return nil, ErrInvalidCount
+	}
+
+	m := monitor.NewMonitor(d.Name, probe, filter, actors,
+		interval, timeout, d.Min, d.Max)
+	m.SetThresholds(d.FailAfter, d.RecoverAfter)
+
+	if d.WarnMin != nil || d.WarnMax != nil {
+		warnMin, warnMax := d.Min, d.Max
+		if d.WarnMin != nil {
+			warnMin = *d.WarnMin
+		}
+		if d.WarnMax != nil {
+			warnMax = *d.WarnMax
+		}
+		if warnMin < d.Min || warnMax > d.Max || warnMin > warnMax {
+			return nil, ErrInvalidWarn
+		}
+		m.SetWarningRange(warnMin, warnMax)
+	}
+
+	if d.RenotifyInterval < 0 {
+		return nil, ErrInvalidRenotify
+	}
+	m.SetRenotifyInterval(time.Duration(d.RenotifyInterval) * time.Second)
+	return m, nil
 }
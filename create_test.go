@@ -27,6 +27,12 @@ func testMonitor1(t *testing.T, m *MonitorDefinition) {
 	if !FloatEquals(m.Max, 0.3) {
 		t.Error(`!FloatEquals(m.Max, 0.3)`)
 	}
+	if m.WarnMin != nil {
+		t.Error(`m.WarnMin != nil`)
+	}
+	if m.WarnMax == nil || !FloatEquals(*m.WarnMax, 0.2) {
+		t.Error(`!FloatEquals(*m.WarnMax, 0.2)`)
+	}
 	if pt, err := getType(m.Probe); err != nil {
 		t.Error(err)
 	} else if pt != "exec" {
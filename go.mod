@@ -7,7 +7,10 @@ require (
 	github.com/cybozu-go/log v1.5.0
 	github.com/cybozu-go/well v1.8.1
 	github.com/go-sql-driver/mysql v1.4.1
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/gorilla/mux v1.6.2
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.0.0-20220722155237-a158d28d115b
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
 )
 
@@ -25,7 +28,6 @@ require (
 	github.com/spf13/jwalterweatherman v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.3 // indirect
 	github.com/spf13/viper v1.2.1 // indirect
-	golang.org/x/net v0.0.0-20220722155237-a158d28d115b // indirect
 	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
 	golang.org/x/text v0.3.8 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
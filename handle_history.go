@@ -0,0 +1,53 @@
+package goma
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/goma/monitor"
+	"github.com/gorilla/mux"
+)
+
+// HistoryEntry represents a probe sample of a monitor.
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Filtered float64   `json:"filtered"`
+	Duration float64   `json:"duration"`
+}
+
+// History represents JSON response for history command.
+// Entries are ordered from the oldest to the newest.
+type History []*HistoryEntry
+
+func handleHistory(w http.ResponseWriter, r *http.Request) {
+	// guaranteed no error by mux.
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	m := monitor.FindMonitor(id)
+	if m == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := make(History, 0)
+	for _, s := range m.History() {
+		h = append(h, &HistoryEntry{
+			Time:     s.Time,
+			Value:    s.Value,
+			Filtered: s.Filtered,
+			Duration: s.Duration.Seconds(),
+		})
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
@@ -18,6 +18,11 @@ func handleList(w http.ResponseWriter, r *http.Request) {
 			Name:    m.Name(),
 			Running: m.Running(),
 			Failing: m.Failing(),
+
+			FailAfter:    m.FailAfter(),
+			RecoverAfter: m.RecoverAfter(),
+			Severity:     m.Severity().String(),
+			Silenced:     m.Silenced(),
 		})
 	}
 
@@ -0,0 +1,142 @@
+package goma
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cybozu-go/goma/monitor"
+)
+
+const (
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	metricsPrefix      = "goma_"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func labels(m *monitor.Monitor) string {
+	return fmt.Sprintf(`id="%d",name="%s"`, m.ID(), labelEscaper.Replace(m.Name()))
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n", metricsPrefix, name, help)
+	fmt.Fprintf(w, "# TYPE %s%s %s\n", metricsPrefix, name, typ)
+}
+
+// writeMetrics writes metrics of monitors in Prometheus text format.
+func writeMetrics(w io.Writer, l []*monitor.Monitor) {
+	type monitorData struct {
+		labels string
+		sample monitor.Sample
+		ok     bool
+		stats  monitor.Stats
+	}
+
+	data := make([]monitorData, 0, len(l))
+	for _, m := range l {
+		s, ok := m.LastSample()
+		data = append(data, monitorData{
+			labels: labels(m),
+			sample: s,
+			ok:     ok,
+			stats:  m.Stats(),
+		})
+	}
+
+	writeHeader(w, "probe_value", "gauge",
+		"The last value returned from the probe.")
+	for _, d := range data {
+		if d.ok {
+			fmt.Fprintf(w, "%sprobe_value{%s} %s\n",
+				metricsPrefix, d.labels, formatFloat(d.sample.Value))
+		}
+	}
+
+	writeHeader(w, "filtered_value", "gauge",
+		"The last probe value after the filter is applied.")
+	for _, d := range data {
+		if d.ok {
+			fmt.Fprintf(w, "%sfiltered_value{%s} %s\n",
+				metricsPrefix, d.labels, formatFloat(d.sample.Filtered))
+		}
+	}
+
+	writeHeader(w, "failing", "gauge",
+		"1 if the monitor is detecting a failure, 0 otherwise.")
+	for i, m := range l {
+		fmt.Fprintf(w, "%sfailing{%s} %s\n",
+			metricsPrefix, data[i].labels, boolValue(m.Failing()))
+	}
+
+	writeHeader(w, "severity", "gauge",
+		"0 for ok, 1 for warning, 2 for critical.")
+	for i, m := range l {
+		fmt.Fprintf(w, "%sseverity{%s} %d\n",
+			metricsPrefix, data[i].labels, int(m.Severity()))
+	}
+
+	writeHeader(w, "running", "gauge",
+		"1 if the monitor is running, 0 otherwise.")
+	for i, m := range l {
+		fmt.Fprintf(w, "%srunning{%s} %s\n",
+			metricsPrefix, data[i].labels, boolValue(m.Running()))
+	}
+
+	writeHeader(w, "probe_duration_seconds", "histogram",
+		"Time taken by probes.")
+	for _, d := range data {
+		for i, b := range monitor.LatencyBuckets {
+			fmt.Fprintf(w, "%sprobe_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				metricsPrefix, d.labels, formatFloat(b), d.stats.LatencyCounts[i])
+		}
+		fmt.Fprintf(w, "%sprobe_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n",
+			metricsPrefix, d.labels, d.stats.LatencyCount)
+		fmt.Fprintf(w, "%sprobe_duration_seconds_sum{%s} %s\n",
+			metricsPrefix, d.labels, formatFloat(d.stats.LatencySum.Seconds()))
+		fmt.Fprintf(w, "%sprobe_duration_seconds_count{%s} %d\n",
+			metricsPrefix, d.labels, d.stats.LatencyCount)
+	}
+
+	writeHeader(w, "failures_total", "counter",
+		"The number of transitions to failure.")
+	for _, d := range data {
+		fmt.Fprintf(w, "%sfailures_total{%s} %d\n",
+			metricsPrefix, d.labels, d.stats.Failures)
+	}
+
+	writeHeader(w, "recoveries_total", "counter",
+		"The number of recoveries from failure.")
+	for _, d := range data {
+		fmt.Fprintf(w, "%srecoveries_total{%s} %d\n",
+			metricsPrefix, d.labels, d.stats.Recoveries)
+	}
+
+	writeHeader(w, "action_errors_total", "counter",
+		"The number of errors returned from actions.")
+	for _, d := range data {
+		fmt.Fprintf(w, "%saction_errors_total{%s} %d\n",
+			metricsPrefix, d.labels, d.stats.ActionErrors)
+	}
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	bw := bufio.NewWriter(w)
+	writeMetrics(bw, monitor.ListMonitors())
+	bw.Flush()
+}
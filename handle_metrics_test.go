@@ -0,0 +1,88 @@
+package goma
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma/actions"
+	"github.com/cybozu-go/goma/monitor"
+)
+
+type testProbe struct {
+	v float64
+}
+
+func (p *testProbe) Probe(ctx context.Context) float64 {
+	return p.v
+}
+
+func (p *testProbe) String() string {
+	return "probe:test"
+}
+
+type testActor struct{}
+
+func (a *testActor) Init(name string) error {
+	return nil
+}
+
+func (a *testActor) Fail(name string, v float64) error {
+	return nil
+}
+
+func (a *testActor) Recover(name string, d time.Duration) error {
+	return nil
+}
+
+func (a *testActor) String() string {
+	return "action:test"
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := monitor.NewMonitor(`mon"1`, &testProbe{v: 2.5}, nil,
+		[]actions.Actor{&testActor{}}, time.Hour, time.Second, 0, 1)
+
+	buf := new(bytes.Buffer)
+	writeMetrics(buf, []*monitor.Monitor{m})
+	out := buf.String()
+	if strings.Contains(out, "goma_probe_value{") {
+		t.Error("probe_value must not be reported before probing")
+	}
+	if !strings.Contains(out, `goma_running{id="-1",name="mon\"1"} 0`+"\n") {
+		t.Error("running is not reported:", out)
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, ok := m.LastSample(); ok {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.Stop()
+
+	buf.Reset()
+	writeMetrics(buf, []*monitor.Monitor{m})
+	out = buf.String()
+	expected := []string{
+		`goma_probe_value{id="-1",name="mon\"1"} 2.5`,
+		`goma_filtered_value{id="-1",name="mon\"1"} 2.5`,
+		`goma_probe_duration_seconds_bucket{id="-1",name="mon\"1",le="+Inf"} 1`,
+		`goma_probe_duration_seconds_count{id="-1",name="mon\"1"} 1`,
+		`goma_failures_total{id="-1",name="mon\"1"} 1`,
+		`goma_recoveries_total{id="-1",name="mon\"1"} 0`,
+		`goma_action_errors_total{id="-1",name="mon\"1"} 0`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e+"\n") {
+			t.Error("missing metric:", e)
+		}
+	}
+}
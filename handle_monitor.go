@@ -18,6 +18,11 @@ type MonitorInfo struct {
 	Name    string `json:"name"`
 	Running bool   `json:"running"`
 	Failing bool   `json:"failing"`
+
+	FailAfter    int    `json:"fail_after"`
+	RecoverAfter int    `json:"recover_after"`
+	Severity     string `json:"severity"`
+	Silenced     bool   `json:"silenced"`
 }
 
 func handleMonitor(w http.ResponseWriter, r *http.Request) {
@@ -36,6 +41,11 @@ func handleMonitor(w http.ResponseWriter, r *http.Request) {
 			Name:    m.Name(),
 			Running: m.Running(),
 			Failing: m.Failing(),
+
+			FailAfter:    m.FailAfter(),
+			RecoverAfter: m.RecoverAfter(),
+			Severity:     m.Severity().String(),
+			Silenced:     m.Silenced(),
 		}
 		data, err := json.Marshal(mi)
 		if err != nil {
@@ -50,6 +60,8 @@ func handleMonitor(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodDelete {
 		m.Stop()
 		monitor.Unregister(m)
+		m.Close()
+		removeDynamicMonitor(id)
 		return
 	}
 
@@ -68,9 +80,12 @@ func handleMonitor(w http.ResponseWriter, r *http.Request) {
 	case "start":
 		if err := m.Start(); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		updateDynamicMonitor(id)
 	case "stop":
 		m.Stop()
+		updateDynamicMonitor(id)
 	default:
 		http.Error(w, "unknown action", http.StatusBadRequest)
 	}
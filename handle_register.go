@@ -40,7 +40,9 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 		"monitor_id": m.ID(),
 		"name":       m.Name(),
 	})
+	watchDynamicMonitor(m)
 	m.Start()
+	addDynamicMonitor(m.ID(), &md)
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Write([]byte(fmt.Sprintf("%d", m.ID())))
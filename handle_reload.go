@@ -0,0 +1,42 @@
+package goma
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/cybozu-go/log"
+)
+
+var (
+	reloaderLock sync.Mutex
+	reloader     func() error
+)
+
+// SetReloader sets a function to reload monitor configurations.
+// The function is called upon POST /reload.
+func SetReloader(f func() error) {
+	reloaderLock.Lock()
+	defer reloaderLock.Unlock()
+
+	reloader = f
+}
+
+func handleReload(w http.ResponseWriter, r *http.Request) {
+	reloaderLock.Lock()
+	f := reloader
+	reloaderLock.Unlock()
+
+	if f == nil {
+		http.Error(w, "reload is not supported", http.StatusNotImplemented)
+		return
+	}
+
+	if err := f(); err != nil {
+		log.Error("failed to reload", map[string]interface{}{
+			"error": err.Error(),
+		})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Info("reloaded", nil)
+}
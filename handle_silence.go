@@ -0,0 +1,135 @@
+package goma
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/goma/monitor"
+	"github.com/cybozu-go/log"
+	"github.com/gorilla/mux"
+)
+
+// SilenceInfo represents a silence.
+//
+// Either MonitorID or Pattern must be specified.
+// Pattern is a shell file name pattern for monitor names.
+type SilenceInfo struct {
+	ID        int       `json:"id,string"`
+	MonitorID *int      `json:"monitor_id,string,omitempty"`
+	Pattern   string    `json:"pattern,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// Silences represents JSON response for silence command.
+type Silences []*SilenceInfo
+
+func newSilenceInfo(s *monitor.Silence) *SilenceInfo {
+	si := &SilenceInfo{
+		ID:      s.ID,
+		Pattern: s.Pattern,
+		Start:   s.Start,
+		End:     s.End,
+		Comment: s.Comment,
+	}
+	if s.MonitorID >= 0 {
+		id := s.MonitorID
+		si.MonitorID = &id
+		si.Pattern = ""
+	}
+	return si
+}
+
+func handleSilences(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		l := make(Silences, 0)
+		for _, s := range monitor.ListSilences() {
+			l = append(l, newSilenceInfo(s))
+		}
+
+		data, err := json.Marshal(l)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(data)
+		return
+	}
+
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if mt != "application/json" {
+		http.Error(w, "bad content type", http.StatusBadRequest)
+		return
+	}
+
+	var si SilenceInfo
+	if err := json.NewDecoder(r.Body).Decode(&si); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s := &monitor.Silence{
+		MonitorID: -1,
+		Pattern:   si.Pattern,
+		Start:     si.Start,
+		End:       si.End,
+		Comment:   si.Comment,
+	}
+	switch {
+	case si.MonitorID != nil && len(si.Pattern) > 0:
+		http.Error(w, "both monitor_id and pattern are specified", http.StatusBadRequest)
+		return
+	case si.MonitorID != nil:
+		if monitor.FindMonitor(*si.MonitorID) == nil {
+			http.Error(w, "no such monitor", http.StatusBadRequest)
+			return
+		}
+		s.MonitorID = *si.MonitorID
+	case len(si.Pattern) == 0:
+		http.Error(w, "monitor_id or pattern is required", http.StatusBadRequest)
+		return
+	}
+	if s.Start.IsZero() {
+		s.Start = time.Now()
+	}
+
+	id, err := monitor.AddSilence(s)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Info("new silence", map[string]interface{}{
+		"silence_id": id,
+		"monitor_id": s.MonitorID,
+		"pattern":    s.Pattern,
+		"start":      s.Start.Format(time.RFC3339),
+		"end":        s.End.Format(time.RFC3339),
+		"comment":    s.Comment,
+	})
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(fmt.Sprintf("%d", id)))
+}
+
+func handleSilence(w http.ResponseWriter, r *http.Request) {
+	// guaranteed no error by mux.
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err := monitor.RemoveSilence(id); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	log.Info("silence removed", map[string]interface{}{
+		"silence_id": id,
+	})
+}
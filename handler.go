@@ -26,12 +26,37 @@ func NewRouter() *mux.Router {
 			handleRegister(w, r)
 		})
 
+	r.Path("/reload").
+		Name("reload").
+		Methods(http.MethodPost).
+		HandlerFunc(handleReload)
+
 	r.Path("/monitor/{id:[0-9]+}").
 		Name("monitor").
 		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			handleMonitor(w, r)
 		})
 
+	r.Path("/monitor/{id:[0-9]+}/history").
+		Name("history").
+		Methods(http.MethodGet).
+		HandlerFunc(handleHistory)
+
+	r.Path("/silences").
+		Name("silences").
+		Methods(http.MethodGet, http.MethodPost).
+		HandlerFunc(handleSilences)
+
+	r.Path("/silences/{id:[0-9]+}").
+		Name("silence").
+		Methods(http.MethodDelete).
+		HandlerFunc(handleSilence)
+
+	r.Path("/metrics").
+		Name("metrics").
+		Methods(http.MethodGet).
+		HandlerFunc(handleMetrics)
+
 	r.Path("/verbosity").
 		Name("verbosity").
 		HandlerFunc(handleVerbosity)
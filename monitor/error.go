@@ -4,7 +4,11 @@ import "errors"
 
 // Errors for monitors.
 var (
-	ErrRegistered    = errors.New("monitor has already been registered")
-	ErrNotRegistered = errors.New("monitor has not been registered")
-	ErrStarted       = errors.New("monitor has already been started")
+	ErrRegistered      = errors.New("monitor has already been registered")
+	ErrNotRegistered   = errors.New("monitor has not been registered")
+	ErrStarted         = errors.New("monitor has already been started")
+	ErrInvalidID       = errors.New("invalid monitor ID")
+	ErrDuplicateID     = errors.New("monitor ID is already in use")
+	ErrInvalidSilence  = errors.New("silence must end after it starts")
+	ErrSilenceNotFound = errors.New("silence not found")
 )
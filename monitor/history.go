@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultHistorySize = 100
+)
+
+// Sample is a record of a probe execution.
+type Sample struct {
+	// Time is the time when the probe started.
+	Time time.Time
+
+	// Value is the raw value returned from the probe.
+	Value float64
+
+	// Filtered is the value after the filter is applied.
+	// This is the same as Value if the monitor has no filter.
+	Filtered float64
+
+	// Duration is the time taken by the probe.
+	Duration time.Duration
+}
+
+// history is a fixed size ring buffer of samples.
+type history struct {
+	lock    sync.Mutex
+	samples []Sample
+	index   int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	return &history{
+		samples: make([]Sample, size),
+	}
+}
+
+func (h *history) put(s Sample) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.samples[h.index] = s
+	h.index++
+	if h.index == len(h.samples) {
+		h.index = 0
+		h.full = true
+	}
+}
+
+// list returns samples ordered from the oldest to the newest.
+func (h *history) list() []Sample {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.full {
+		l := make([]Sample, h.index)
+		copy(l, h.samples[:h.index])
+		return l
+	}
+
+	l := make([]Sample, 0, len(h.samples))
+	l = append(l, h.samples[h.index:]...)
+	l = append(l, h.samples[:h.index]...)
+	return l
+}
+
+func (h *history) last() (s Sample, ok bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.full && h.index == 0 {
+		return
+	}
+
+	i := h.index - 1
+	if i < 0 {
+		i = len(h.samples) - 1
+	}
+	return h.samples[i], true
+}
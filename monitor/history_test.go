@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	h := newHistory(3)
+	if len(h.list()) != 0 {
+		t.Error(`len(h.list()) != 0`)
+	}
+
+	h.put(Sample{Value: 1})
+	h.put(Sample{Value: 2})
+	l := h.list()
+	if len(l) != 2 {
+		t.Fatal(`len(l) != 2`)
+	}
+	if l[0].Value != 1 || l[1].Value != 2 {
+		t.Error(`wrong order:`, l)
+	}
+
+	h.put(Sample{Value: 3})
+	h.put(Sample{Value: 4})
+	h.put(Sample{Value: 5})
+	l = h.list()
+	if len(l) != 3 {
+		t.Fatal(`len(l) != 3`)
+	}
+	if l[0].Value != 3 || l[1].Value != 4 || l[2].Value != 5 {
+		t.Error(`wrong order:`, l)
+	}
+}
@@ -3,6 +3,7 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -28,7 +29,28 @@ type Monitor struct {
 	timeout  time.Duration
 	min      float64
 	max      float64
-	failedAt *time.Time
+	history  *history
+	stats    *stats
+
+	// the number of consecutive results to change the state.
+	failAfter    int
+	recoverAfter int
+
+	// the range for probe results without warnings.
+	hasWarnRange bool
+	warnMin      float64
+	warnMax      float64
+
+	// interval to re-notify ongoing failures.  Zero disables it.
+	renotifyInterval time.Duration
+
+	// called when the monitor stops by itself.
+	stopHandler func()
+
+	// failure state
+	stateLock sync.Mutex
+	failedAt  *time.Time
+	severity  actions.Severity
 
 	// goroutine management
 	lock sync.Mutex
@@ -59,9 +81,58 @@ func NewMonitor(
 		timeout:  timeout,
 		min:      min,
 		max:      max,
+		history:  newHistory(defaultHistorySize),
+		stats:    newStats(),
+
+		failAfter:    1,
+		recoverAfter: 1,
 	}
 }
 
+// SetThresholds sets the number of consecutive failures required
+// to start failing, and the number of consecutive successes required
+// to recover from failure.  Both default to 1.
+//
+// Values less than 1 are treated as 1.
+// This must be called before Start.
+func (m *Monitor) SetThresholds(failAfter, recoverAfter int) {
+	if failAfter < 1 {
+		failAfter = 1
+	}
+	if recoverAfter < 1 {
+		recoverAfter = 1
+	}
+	m.failAfter = failAfter
+	m.recoverAfter = recoverAfter
+}
+
+// SetWarningRange sets the range for probe results without warnings.
+// Results within min and max but out of this range are warnings.
+//
+// This must be called before Start.
+func (m *Monitor) SetWarningRange(min, max float64) {
+	m.hasWarnRange = true
+	m.warnMin = min
+	m.warnMax = max
+}
+
+// SetRenotifyInterval sets the interval to notify actions again
+// while the monitor keeps failing.  Zero disables re-notification.
+//
+// This must be called before Start.
+func (m *Monitor) SetRenotifyInterval(d time.Duration) {
+	m.renotifyInterval = d
+}
+
+// SetStopHandler sets a function to be called when the monitor stops
+// by itself because an action fails to initialize.  It is not called
+// by Stop.
+//
+// This must be called before Start.
+func (m *Monitor) SetStopHandler(f func()) {
+	m.stopHandler = f
+}
+
 // Start starts monitoring.
 // If already started, this returns a non-nil error.
 func (m *Monitor) Start() error {
@@ -99,24 +170,116 @@ func (m *Monitor) Stop() {
 	m.env.Wait()
 	m.env = nil
 
-	m.failedAt = nil
+	m.setState(nil, actions.SeverityOK)
 
 	log.Info("monitor stopped", map[string]interface{}{
 		"monitor": m.name,
 	})
 }
 
+// Close releases resources held by the probe if it implements io.Closer.
+// The monitor must be stopped and must not be started again.
+func (m *Monitor) Close() error {
+	if c, ok := m.probe.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 func (m *Monitor) die() {
 	m.lock.Lock()
-	defer m.lock.Unlock()
-
 	m.env = nil
+	m.lock.Unlock()
+
+	if m.stopHandler != nil {
+		m.stopHandler()
+	}
 }
 
-func callProbe(ctx context.Context, p probes.Prober, timeout time.Duration) float64 {
+func callProbe(ctx context.Context, p probes.Prober, timeout time.Duration) (float64, time.Duration) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	return p.Probe(ctx)
+	start := time.Now()
+	v := p.Probe(ctx)
+	return v, time.Since(start)
+}
+
+// setState updates the failure state.
+//
+// The state is written only by the goroutine running the monitor,
+// so the goroutine can read the state without the lock.
+func (m *Monitor) setState(failedAt *time.Time, sev actions.Severity) {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+
+	m.failedAt = failedAt
+	m.severity = sev
+}
+
+// severityOf returns the severity of a probe value.
+func (m *Monitor) severityOf(v float64) actions.Severity {
+	if (v < m.min) || (m.max < v) {
+		return actions.SeverityCritical
+	}
+	if m.hasWarnRange && ((v < m.warnMin) || (m.warnMax < v)) {
+		return actions.SeverityWarning
+	}
+	return actions.SeverityOK
+}
+
+// notifyFail calls actions for a failure.
+// start is true when the failure has just started.  Actions that do not
+// implement actions.SeverityActor are called only for a new failure,
+// so they receive Fail for a warning as well as for a critical failure,
+// and are not notified when a warning escalates to critical.
+func (m *Monitor) notifyFail(v float64, sev actions.Severity, start bool) {
+	for _, a := range m.actors {
+		var err error
+		if sa, ok := a.(actions.SeverityActor); ok {
+			err = sa.FailWithSeverity(m.name, v, sev)
+		} else if start {
+			err = a.Fail(m.name, v)
+		}
+		if err != nil {
+			m.stats.actionError()
+			log.Error("failed to call Actor.Fail", map[string]interface{}{
+				"monitor": m.name,
+				"action":  a.String(),
+			})
+		}
+	}
+}
+
+// notifyOngoing calls actions for an ongoing failure.
+func (m *Monitor) notifyOngoing(v float64, sev actions.Severity, d time.Duration) {
+	for _, a := range m.actors {
+		var err error
+		if oa, ok := a.(actions.OngoingActor); ok {
+			err = oa.Ongoing(m.name, v, sev, d)
+		} else {
+			err = a.Fail(m.name, v)
+		}
+		if err != nil {
+			m.stats.actionError()
+			log.Error("failed to call Actor.Ongoing", map[string]interface{}{
+				"monitor": m.name,
+				"action":  a.String(),
+			})
+		}
+	}
+}
+
+// notifyRecover calls actions for a recovery.
+func (m *Monitor) notifyRecover(d time.Duration) {
+	for _, a := range m.actors {
+		if err := a.Recover(m.name, d); err != nil {
+			m.stats.actionError()
+			log.Error("failed to call Actor.Recover", map[string]interface{}{
+				"monitor": m.name,
+				"action":  a.String(),
+			})
+		}
+	}
 }
 
 func (m *Monitor) run(ctx context.Context) error {
@@ -126,6 +289,7 @@ func (m *Monitor) run(ctx context.Context) error {
 	for _, a := range m.actors {
 		err := a.Init(m.name)
 		if err != nil {
+			m.stats.actionError()
 			log.Error("failed to init action", map[string]interface{}{
 				"monitor": m.name,
 				"action":  a.String(),
@@ -135,12 +299,27 @@ func (m *Monitor) run(ctx context.Context) error {
 		}
 	}
 
+	// the number of consecutive failures or successes.
+	var failCount, okCount int
+
+	// the last time actions are notified of the current failure.
+	var notifiedAt time.Time
+
+	// the last time the monitor recovered.
+	var recoveredAt time.Time
+
+	// silence state, and the failure state before the silence.
+	var wasSilenced bool
+	var beforeFailedAt *time.Time
+	var beforeSeverity actions.Severity
+
 	for {
 		// create a timer before starting probe.
 		// This way, we can keep consistent interval between probes.
 		t := time.After(m.interval)
 
-		v := callProbe(ctx, m.probe, m.timeout)
+		probedAt := time.Now()
+		raw, elapsed := callProbe(ctx, m.probe, m.timeout)
 
 		// check cancel
 		select {
@@ -150,39 +329,82 @@ func (m *Monitor) run(ctx context.Context) error {
 			// not canceled
 		}
 
+		v := raw
 		if m.filter != nil {
 			v = m.filter.Put(v)
 		}
-
-		if (v < m.min) || (m.max < v) {
-			if m.failedAt == nil {
+		m.history.put(Sample{
+			Time:     probedAt,
+			Value:    raw,
+			Filtered: v,
+			Duration: elapsed,
+		})
+		m.stats.observe(elapsed)
+
+		// actions are not called while silenced.  When the silence ends,
+		// actions are notified of the state change during the silence.
+		silenced := m.Silenced()
+		if silenced && !wasSilenced {
+			beforeFailedAt, beforeSeverity = m.failedAt, m.severity
+			log.Info("monitor silenced", map[string]interface{}{
+				"monitor": m.name,
+			})
+		}
+		quiet := silenced || wasSilenced
+
+		if sev := m.severityOf(v); sev != actions.SeverityOK {
+			failCount++
+			okCount = 0
+			switch {
+			case m.failedAt == nil && failCount < m.failAfter:
+				// failAfter applies only to a new failure.
+			case m.failedAt == nil:
 				now := time.Now()
-				m.failedAt = &now
-				for _, a := range m.actors {
-					if err := a.Fail(m.name, v); err != nil {
-						log.Error("failed to call Actor.Fail", map[string]interface{}{
-							"monitor": m.name,
-							"action":  a.String(),
-						})
-					}
+				m.setState(&now, sev)
+				m.stats.failed()
+				if !quiet {
+					m.notifyFail(v, sev, true)
+					notifiedAt = now
 				}
 				log.Warn("monitor failure", map[string]interface{}{
-					"monitor": m.name,
-					"value":   fmt.Sprint(v),
+					"monitor":  m.name,
+					"value":    fmt.Sprint(v),
+					"severity": sev.String(),
+				})
+			case m.severity != sev:
+				m.setState(m.failedAt, sev)
+				if !quiet {
+					m.notifyFail(v, sev, false)
+					notifiedAt = time.Now()
+				}
+				log.Warn("monitor severity changed", map[string]interface{}{
+					"monitor":  m.name,
+					"value":    fmt.Sprint(v),
+					"severity": sev.String(),
+				})
+			case quiet:
+			case m.renotifyInterval > 0 && time.Since(notifiedAt) >= m.renotifyInterval:
+				d := time.Since(*m.failedAt)
+				m.notifyOngoing(v, sev, d)
+				notifiedAt = time.Now()
+				log.Warn("monitor failure continues", map[string]interface{}{
+					"monitor":  m.name,
+					"value":    fmt.Sprint(v),
+					"severity": sev.String(),
+					"duration": int(d.Seconds()),
 				})
 			}
 		} else {
-			if m.failedAt != nil {
-				d := time.Since(*m.failedAt)
-				for _, a := range m.actors {
-					if err := a.Recover(m.name, d); err != nil {
-						log.Error("failed to call Actor.Recover", map[string]interface{}{
-							"monitor": m.name,
-							"action":  a.String(),
-						})
-					}
+			okCount++
+			failCount = 0
+			if m.failedAt != nil && okCount >= m.recoverAfter {
+				recoveredAt = time.Now()
+				d := recoveredAt.Sub(*m.failedAt)
+				m.stats.recovered()
+				if !quiet {
+					m.notifyRecover(d)
 				}
-				m.failedAt = nil
+				m.setState(nil, actions.SeverityOK)
 				log.Warn("monitor recovery", map[string]interface{}{
 					"monitor":  m.name,
 					"duration": int(d.Seconds()),
@@ -190,6 +412,28 @@ func (m *Monitor) run(ctx context.Context) error {
 			}
 		}
 
+		if wasSilenced && !silenced {
+			switch {
+			case beforeFailedAt == nil && m.failedAt != nil:
+				m.notifyFail(v, m.severity, true)
+				notifiedAt = time.Now()
+			case beforeFailedAt != nil && m.failedAt == nil:
+				m.notifyRecover(recoveredAt.Sub(*beforeFailedAt))
+			case beforeFailedAt != nil && !m.failedAt.Equal(*beforeFailedAt):
+				// recovered and failed again during the silence.
+				m.notifyRecover(recoveredAt.Sub(*beforeFailedAt))
+				m.notifyFail(v, m.severity, true)
+				notifiedAt = time.Now()
+			case beforeFailedAt != nil && beforeSeverity != m.severity:
+				m.notifyFail(v, m.severity, false)
+				notifiedAt = time.Now()
+			}
+			log.Info("monitor silence ended", map[string]interface{}{
+				"monitor": m.name,
+			})
+		}
+		wasSilenced = silenced
+
 		select {
 		case <-ctx.Done():
 			return nil
@@ -216,11 +460,53 @@ func (m *Monitor) String() string {
 	return m.name
 }
 
+// FailAfter returns the number of consecutive failures required
+// to start failing.
+func (m *Monitor) FailAfter() int {
+	return m.failAfter
+}
+
+// RecoverAfter returns the number of consecutive successes required
+// to recover from failure.
+func (m *Monitor) RecoverAfter() int {
+	return m.recoverAfter
+}
+
 // Failing returns true if the monitor is detecting a failure.
+// Warnings are failures, too; use Severity to distinguish them.
 func (m *Monitor) Failing() bool {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+
 	return m.failedAt != nil
 }
 
+// History returns recent probe samples ordered from the oldest
+// to the newest.  The number of samples is bounded.
+func (m *Monitor) History() []Sample {
+	return m.history.list()
+}
+
+// LastSample returns the most recent probe sample.
+// If the monitor has never probed, ok is false.
+func (m *Monitor) LastSample() (s Sample, ok bool) {
+	return m.history.last()
+}
+
+// Stats returns a snapshot of statistics of the monitor.
+func (m *Monitor) Stats() Stats {
+	return m.stats.snapshot()
+}
+
+// Severity returns the severity of the current failure.
+// This returns actions.SeverityOK if the monitor is not failing.
+func (m *Monitor) Severity() actions.Severity {
+	m.stateLock.Lock()
+	defer m.stateLock.Unlock()
+
+	return m.severity
+}
+
 // Running returns true if the monitor is running.
 func (m *Monitor) Running() bool {
 	m.lock.Lock()
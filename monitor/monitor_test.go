@@ -0,0 +1,286 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma/actions"
+)
+
+// seqProbe returns values in order, then repeats the last value.
+type seqProbe struct {
+	lock   sync.Mutex
+	values []float64
+}
+
+func (p *seqProbe) Probe(ctx context.Context) float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	v := p.values[0]
+	if len(p.values) > 1 {
+		p.values = p.values[1:]
+	}
+	return v
+}
+
+func (p *seqProbe) String() string {
+	return "probe:seq"
+}
+
+type event struct {
+	name     string
+	value    float64
+	severity actions.Severity
+}
+
+// recordActor sends events to its channel.
+type recordActor struct {
+	ch chan event
+}
+
+func newRecordActor() *recordActor {
+	return &recordActor{ch: make(chan event, 100)}
+}
+
+func (a *recordActor) Init(name string) error {
+	return nil
+}
+
+func (a *recordActor) Fail(name string, v float64) error {
+	return a.FailWithSeverity(name, v, actions.SeverityCritical)
+}
+
+func (a *recordActor) FailWithSeverity(name string, v float64, s actions.Severity) error {
+	a.ch <- event{"fail", v, s}
+	return nil
+}
+
+func (a *recordActor) Ongoing(name string, v float64, s actions.Severity, d time.Duration) error {
+	a.ch <- event{"ongoing", v, s}
+	return nil
+}
+
+func (a *recordActor) Recover(name string, d time.Duration) error {
+	a.ch <- event{"recover", 0, actions.SeverityOK}
+	return nil
+}
+
+func (a *recordActor) String() string {
+	return "action:record"
+}
+
+func (a *recordActor) next(t *testing.T) event {
+	t.Helper()
+	select {
+	case e := <-a.ch:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	return event{}
+}
+
+// legacyActor implements only actions.Actor.
+// Fail events are recorded with SeverityOK as the severity is unknown.
+type legacyActor struct {
+	r *recordActor
+}
+
+func (a legacyActor) Init(name string) error {
+	return nil
+}
+
+func (a legacyActor) Fail(name string, v float64) error {
+	a.r.ch <- event{"fail", v, actions.SeverityOK}
+	return nil
+}
+
+func (a legacyActor) Recover(name string, d time.Duration) error {
+	return a.r.Recover(name, d)
+}
+
+func (a legacyActor) String() string {
+	return "action:legacy"
+}
+
+// initFailActor fails to initialize.
+type initFailActor struct {
+	legacyActor
+}
+
+func (a initFailActor) Init(name string) error {
+	return errors.New("init failed")
+}
+
+func testMonitor(values []float64, a *recordActor) *Monitor {
+	return NewMonitor("test", &seqProbe{values: values}, nil,
+		[]actions.Actor{a}, 5*time.Millisecond, time.Second, 0, 1)
+}
+
+func TestMonitorThresholds(t *testing.T) {
+	t.Parallel()
+
+	a := newRecordActor()
+	m := testMonitor([]float64{2, 0, 2, 2, 0, 2, 0, 0, 0}, a)
+	m.SetThresholds(2, 3)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	e := a.next(t)
+	if e.name != "fail" {
+		t.Fatal(`e.name != "fail"`, e)
+	}
+	// the first failure is ignored, so this is the 4th value.
+	if len(m.History()) < 4 {
+		t.Error(`monitor failed too early`)
+	}
+
+	e = a.next(t)
+	if e.name != "recover" {
+		t.Fatal(`e.name != "recover"`, e)
+	}
+	if len(m.History()) < 9 {
+		t.Error(`monitor recovered too early`)
+	}
+}
+
+func TestMonitorThresholdsEscalation(t *testing.T) {
+	t.Parallel()
+
+	a := newRecordActor()
+	m := testMonitor([]float64{0.9, 0.9, 0.9, 0.5, 2, 3, 4}, a)
+	m.SetThresholds(3, 3)
+	m.SetWarningRange(0, 0.8)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	// an ongoing failure escalates immediately even after a normal
+	// output that is not enough to recover.
+	expected := []event{
+		{"fail", 0.9, actions.SeverityWarning},
+		{"fail", 2, actions.SeverityCritical},
+	}
+	for _, ee := range expected {
+		e := a.next(t)
+		if e != ee {
+			t.Errorf("expected %v, got %v", ee, e)
+		}
+	}
+}
+
+func TestMonitorSeverity(t *testing.T) {
+	t.Parallel()
+
+	a := newRecordActor()
+	m := testMonitor([]float64{0.5, 0.9, 2, 0.9, 0.5}, a)
+	m.SetWarningRange(0, 0.8)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	expected := []event{
+		{"fail", 0.9, actions.SeverityWarning},
+		{"fail", 2, actions.SeverityCritical},
+		{"fail", 0.9, actions.SeverityWarning},
+		{"recover", 0, actions.SeverityOK},
+	}
+	for _, ee := range expected {
+		e := a.next(t)
+		if e != ee {
+			t.Errorf("expected %v, got %v", ee, e)
+		}
+	}
+	if m.Severity() != actions.SeverityOK {
+		t.Error(`m.Severity() != actions.SeverityOK`)
+	}
+}
+
+func TestMonitorSeverityLegacy(t *testing.T) {
+	t.Parallel()
+
+	r := newRecordActor()
+	m := NewMonitor("test", &seqProbe{values: []float64{0.5, 0.9, 2, 0.9, 0.5}}, nil,
+		[]actions.Actor{legacyActor{r}}, 5*time.Millisecond, time.Second, 0, 1)
+	m.SetWarningRange(0, 0.8)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	// a warning starts a failure, and the escalation is not notified.
+	expected := []event{
+		{"fail", 0.9, actions.SeverityOK},
+		{"recover", 0, actions.SeverityOK},
+	}
+	for _, ee := range expected {
+		e := r.next(t)
+		if e != ee {
+			t.Errorf("expected %v, got %v", ee, e)
+		}
+	}
+	if m.Stats().Failures != 1 {
+		t.Error(`m.Stats().Failures != 1`, m.Stats().Failures)
+	}
+}
+
+func TestMonitorStopHandler(t *testing.T) {
+	t.Parallel()
+
+	stopped := make(chan struct{})
+	m := NewMonitor("test", &seqProbe{values: []float64{0}}, nil,
+		[]actions.Actor{initFailActor{}}, 5*time.Millisecond, time.Second, 0, 1)
+	m.SetStopHandler(func() {
+		if m.Running() {
+			t.Error("monitor should not be running")
+		}
+		close(stopped)
+	})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop handler is not called")
+	}
+}
+
+func TestMonitorRenotify(t *testing.T) {
+	t.Parallel()
+
+	a := newRecordActor()
+	m := testMonitor([]float64{2}, a)
+	m.SetRenotifyInterval(20 * time.Millisecond)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	e := a.next(t)
+	if e.name != "fail" {
+		t.Fatal(`e.name != "fail"`, e)
+	}
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		e = a.next(t)
+		if e.name != "ongoing" {
+			t.Fatal(`e.name != "ongoing"`, e)
+		}
+		if e.value != 2 || e.severity != actions.SeverityCritical {
+			t.Error("unexpected event:", e)
+		}
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("renotified too early")
+	}
+}
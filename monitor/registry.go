@@ -1,6 +1,9 @@
 package monitor
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 const (
 	uninitializedID = -1
@@ -21,12 +24,41 @@ func Register(m *Monitor) error {
 	registryLock.Lock()
 	defer registryLock.Unlock()
 
+	for {
+		if _, ok := registry[registryIndex]; !ok {
+			break
+		}
+		registryIndex++
+	}
+
 	m.id = registryIndex
 	registry[registryIndex] = m
 	registryIndex++
 	return nil
 }
 
+// RegisterWithID registers a monitor with the given ID.
+// This is used to restore monitors keeping their IDs.
+func RegisterWithID(m *Monitor, id int) error {
+	if m.id != uninitializedID {
+		return ErrRegistered
+	}
+	if id < 0 {
+		return ErrInvalidID
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, ok := registry[id]; ok {
+		return ErrDuplicateID
+	}
+
+	m.id = id
+	registry[id] = m
+	return nil
+}
+
 // FindMonitor looks up a monitor in the registry.
 // If not found, nil is returned.
 func FindMonitor(id int) *Monitor {
@@ -57,12 +89,12 @@ func ListMonitors() []*Monitor {
 	defer registryLock.Unlock()
 
 	l := make([]*Monitor, 0, len(registry))
-
-	for i := 0; i < registryIndex; i++ {
-		if m, ok := registry[i]; ok {
-			l = append(l, m)
-		}
+	for _, m := range registry {
+		l = append(l, m)
 	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].id < l[j].id
+	})
 
 	return l
 }
@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Silence suppresses actions of matching monitors for a period.
+//
+// Silenced monitors keep probing and tracking failures, but do not
+// call actions.  When the silence ends, actions are notified of the
+// difference between the states before and after the silence.
+type Silence struct {
+	// ID is assigned by AddSilence.
+	ID int
+
+	// MonitorID is the ID of the monitor to be silenced.
+	// If MonitorID is negative, Pattern is used instead.
+	MonitorID int
+
+	// Pattern is a shell file name pattern for monitor names.
+	// See path.Match for the syntax.
+	Pattern string
+
+	Start   time.Time
+	End     time.Time
+	Comment string
+}
+
+// Matches returns true if s matches m.
+func (s *Silence) Matches(m *Monitor) bool {
+	if s.MonitorID >= 0 {
+		return s.MonitorID == m.ID()
+	}
+	ok, _ := path.Match(s.Pattern, m.Name())
+	return ok
+}
+
+// Active returns true if s is active at t.
+func (s *Silence) Active(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+var (
+	silenceLock  = new(sync.Mutex)
+	silences     = make(map[int]*Silence)
+	silenceIndex int
+)
+
+// AddSilence adds a silence and returns its ID.
+func AddSilence(s *Silence) (int, error) {
+	if s.MonitorID < 0 {
+		if _, err := path.Match(s.Pattern, ""); err != nil {
+			return 0, err
+		}
+	}
+	if !s.Start.Before(s.End) {
+		return 0, ErrInvalidSilence
+	}
+
+	silenceLock.Lock()
+	defer silenceLock.Unlock()
+
+	s.ID = silenceIndex
+	silences[silenceIndex] = s
+	silenceIndex++
+	return s.ID, nil
+}
+
+// RemoveSilence removes a silence.
+func RemoveSilence(id int) error {
+	silenceLock.Lock()
+	defer silenceLock.Unlock()
+
+	if _, ok := silences[id]; !ok {
+		return ErrSilenceNotFound
+	}
+	delete(silences, id)
+	return nil
+}
+
+// removeExpiredSilences removes silences that have ended.
+// The caller must hold silenceLock.
+func removeExpiredSilences(now time.Time) {
+	for id, s := range silences {
+		if !now.Before(s.End) {
+			delete(silences, id)
+		}
+	}
+}
+
+// ListSilences returns a list of silences that have not ended,
+// ordered by ID (ascending).
+func ListSilences() []*Silence {
+	silenceLock.Lock()
+	defer silenceLock.Unlock()
+
+	removeExpiredSilences(time.Now())
+
+	l := make([]*Silence, 0, len(silences))
+	for _, s := range silences {
+		l = append(l, s)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].ID < l[j].ID
+	})
+	return l
+}
+
+// Silenced returns true if the monitor is silenced now.
+func (m *Monitor) Silenced() bool {
+	now := time.Now()
+
+	silenceLock.Lock()
+	defer silenceLock.Unlock()
+
+	removeExpiredSilences(now)
+	for _, s := range silences {
+		if s.Active(now) && s.Matches(m) {
+			return true
+		}
+	}
+	return false
+}
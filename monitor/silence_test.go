@@ -0,0 +1,165 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma/actions"
+)
+
+func TestAddSilence(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	_, err := AddSilence(&Silence{
+		MonitorID: -1,
+		Pattern:   "[",
+		Start:     now,
+		End:       now.Add(time.Hour),
+	})
+	if err == nil {
+		t.Error("bad pattern should be rejected")
+	}
+
+	_, err = AddSilence(&Silence{
+		MonitorID: 0,
+		Start:     now,
+		End:       now,
+	})
+	if err != ErrInvalidSilence {
+		t.Error(`err != ErrInvalidSilence`)
+	}
+
+	id, err := AddSilence(&Silence{
+		MonitorID: -1,
+		Pattern:   "add-silence-*",
+		Start:     now,
+		End:       now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMonitor("add-silence-1", &seqProbe{values: []float64{0}}, nil,
+		nil, time.Second, time.Second, 0, 1)
+	if !m.Silenced() {
+		t.Error(`!m.Silenced()`)
+	}
+
+	if err := RemoveSilence(id); err != nil {
+		t.Fatal(err)
+	}
+	if m.Silenced() {
+		t.Error(`m.Silenced()`)
+	}
+	if err := RemoveSilence(id); err != ErrSilenceNotFound {
+		t.Error(`err != ErrSilenceNotFound`)
+	}
+}
+
+func TestMonitorSilence(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	end := now.Add(100 * time.Millisecond)
+	_, err := AddSilence(&Silence{
+		MonitorID: -1,
+		Pattern:   "silenced",
+		Start:     now,
+		End:       end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newRecordActor()
+	m := NewMonitor("silenced", &seqProbe{values: []float64{0, 2}}, nil,
+		[]actions.Actor{a}, 5*time.Millisecond, time.Second, 0, 1)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	e := a.next(t)
+	if e.name != "fail" {
+		t.Fatal(`e.name != "fail"`, e)
+	}
+	if time.Now().Before(end) {
+		t.Error("actions are called during silence")
+	}
+	if !m.Failing() {
+		t.Error(`!m.Failing()`)
+	}
+}
+
+// valueProbe returns the value set by set.
+type valueProbe struct {
+	lock  sync.Mutex
+	value float64
+}
+
+func (p *valueProbe) Probe(ctx context.Context) float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.value
+}
+
+func (p *valueProbe) set(v float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.value = v
+}
+
+func (p *valueProbe) String() string {
+	return "probe:value"
+}
+
+func TestMonitorSilenceRefail(t *testing.T) {
+	t.Parallel()
+
+	a := newRecordActor()
+	p := &valueProbe{value: 2}
+	m := NewMonitor("silenced-refail", p, nil,
+		[]actions.Actor{a}, 5*time.Millisecond, time.Second, 0, 1)
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	if e := a.next(t); e.name != "fail" {
+		t.Fatal(`e.name != "fail"`, e)
+	}
+
+	now := time.Now()
+	end := now.Add(200 * time.Millisecond)
+	_, err := AddSilence(&Silence{
+		MonitorID: -1,
+		Pattern:   "silenced-refail",
+		Start:     now,
+		End:       end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recover and fail again at the same severity during the silence.
+	time.Sleep(50 * time.Millisecond)
+	p.set(0)
+	time.Sleep(50 * time.Millisecond)
+	p.set(2)
+
+	e := a.next(t)
+	if time.Now().Before(end) {
+		t.Error("actions are called during silence")
+	}
+	if e.name != "recover" {
+		t.Error(`e.name != "recover"`, e)
+	}
+	if e := a.next(t); e.name != "fail" {
+		t.Error(`e.name != "fail"`, e)
+	}
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds in seconds of the probe latency
+// histogram buckets.
+var LatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60,
+}
+
+// Stats is a snapshot of statistics of a monitor.
+type Stats struct {
+	// Failures is the number of transitions to failure.
+	Failures uint64
+
+	// Recoveries is the number of transitions from failure.
+	Recoveries uint64
+
+	// ActionErrors is the number of errors returned from actions.
+	ActionErrors uint64
+
+	// LatencyCounts[i] is the number of probes that took
+	// LatencyBuckets[i] seconds or less.  Counts are cumulative.
+	LatencyCounts []uint64
+
+	// LatencyCount is the total number of probes.
+	LatencyCount uint64
+
+	// LatencySum is the total time taken by probes.
+	LatencySum time.Duration
+}
+
+type stats struct {
+	lock sync.Mutex
+	s    Stats
+}
+
+func newStats() *stats {
+	return &stats{
+		s: Stats{
+			LatencyCounts: make([]uint64, len(LatencyBuckets)),
+		},
+	}
+}
+
+func (st *stats) observe(d time.Duration) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	sec := d.Seconds()
+	for i, b := range LatencyBuckets {
+		if sec <= b {
+			st.s.LatencyCounts[i]++
+		}
+	}
+	st.s.LatencyCount++
+	st.s.LatencySum += d
+}
+
+func (st *stats) failed() {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	st.s.Failures++
+}
+
+func (st *stats) recovered() {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	st.s.Recoveries++
+}
+
+func (st *stats) actionError() {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	st.s.ActionErrors++
+}
+
+func (st *stats) snapshot() Stats {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	s := st.s
+	s.LatencyCounts = make([]uint64, len(st.s.LatencyCounts))
+	copy(s.LatencyCounts, st.s.LatencyCounts)
+	return s
+}
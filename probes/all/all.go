@@ -3,7 +3,24 @@ package all
 
 import (
 	// import all probes
+	_ "github.com/cybozu-go/goma/probes/banner"
+	_ "github.com/cybozu-go/goma/probes/disk"
+	_ "github.com/cybozu-go/goma/probes/dns"
 	_ "github.com/cybozu-go/goma/probes/exec"
+	_ "github.com/cybozu-go/goma/probes/file"
 	_ "github.com/cybozu-go/goma/probes/http"
+	_ "github.com/cybozu-go/goma/probes/inode"
+	_ "github.com/cybozu-go/goma/probes/loadavg"
+	_ "github.com/cybozu-go/goma/probes/logmatch"
+	_ "github.com/cybozu-go/goma/probes/memcached"
+	_ "github.com/cybozu-go/goma/probes/memory"
 	_ "github.com/cybozu-go/goma/probes/mysql"
+	_ "github.com/cybozu-go/goma/probes/ping"
+	_ "github.com/cybozu-go/goma/probes/postgresql"
+	_ "github.com/cybozu-go/goma/probes/process"
+	_ "github.com/cybozu-go/goma/probes/redis"
+	_ "github.com/cybozu-go/goma/probes/smtp"
+	_ "github.com/cybozu-go/goma/probes/sql"
+	_ "github.com/cybozu-go/goma/probes/tcp"
+	_ "github.com/cybozu-go/goma/probes/tls"
 )
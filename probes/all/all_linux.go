@@ -0,0 +1,6 @@
+package all
+
+import (
+	// import probes available only on Linux
+	_ "github.com/cybozu-go/goma/probes/systemd"
+)
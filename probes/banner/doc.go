@@ -0,0 +1,41 @@
+/*
+Package banner implements "banner" probe type that tests line-oriented
+protocols such as IMAP, POP3, or FTP with a send/expect script.
+
+The probe connects to the server and runs script, a list of steps
+in one of these forms:
+
+	Step            Description
+	expect REGEXP   Read lines until a line matches REGEXP.
+	send TEXT       Send TEXT followed by CRLF.
+
+The script usually begins with an "expect" step to check the greeting.
+For example, an IMAP server can be checked by:
+
+	script = ["expect ^\\* OK", "send a1 LOGOUT", "expect ^a1 OK"]
+
+and a POP3 server by:
+
+	script = ["expect ^\\+OK", "send QUIT", "expect ^\\+OK"]
+
+If mode is "latency", the value of the probe will be the time in
+seconds taken to complete the script.  If mode is "status", the value
+will be 0.  If the probe fails to connect, or the connection is closed
+before an "expect" step matches, errval is returned.  Unmatched
+"expect" steps fail when the probe times out.
+
+The constructor takes these parameters:
+
+	Name                  Type      Default   Description
+	address               string              host:port to connect.  Required.
+	script                []string            Steps described above.  Required.
+	tls                   bool      false     If true, connect with TLS.
+	server_name           string              Server name for TLS.
+	                                          Defaults to the host of address.
+	insecure_skip_verify  bool      false     If true, skip TLS certificate checks.
+	mode                  string    latency   "latency" or "status".
+	errval                float64   (*)       Return value upon an error.
+
+(*) errval defaults to -1 for "latency" and 1 for "status".
+*/
+package banner
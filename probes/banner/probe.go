@@ -0,0 +1,248 @@
+package banner
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeLatency = "latency"
+	modeStatus  = "status"
+
+	defaultLatencyErrval = -1.0
+	defaultStatusErrval  = 1.0
+
+	// maxLineSize limits the size of a line read from the server.
+	maxLineSize = 64 * 1024
+)
+
+var (
+	errEmptyScript = errors.New("empty script")
+	errLongLine    = errors.New("too long line")
+)
+
+// step is either to send a line or to expect a line.
+type step struct {
+	send   string
+	expect *regexp.Regexp
+}
+
+type probe struct {
+	address   string
+	script    []step
+	tlsConfig *tls.Config
+	mode      string
+	errval    float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if p.tlsConfig != nil {
+		tc := tls.Client(conn, p.tlsConfig)
+		if err := tc.Handshake(); err != nil {
+			return p.fail(err)
+		}
+		conn = tc
+	}
+
+	r := bufio.NewReaderSize(conn, 4096)
+	for _, s := range p.script {
+		if s.expect == nil {
+			if _, err := io.WriteString(conn, s.send+"\r\n"); err != nil {
+				return p.fail(err)
+			}
+			continue
+		}
+		if err := expectLine(r, s.expect); err != nil {
+			return p.fail(fmt.Errorf("expect %s: %v", s.expect, err))
+		}
+	}
+
+	if p.mode == modeStatus {
+		return 0
+	}
+	return time.Since(start).Seconds()
+}
+
+// expectLine reads lines from r until a line matches re.
+func expectLine(r *bufio.Reader, re *regexp.Regexp) error {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+		if re.MatchString(line) {
+			return nil
+		}
+	}
+}
+
+// readLine reads a line without the trailing CRLF or LF.
+func readLine(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		l, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		b.Write(l)
+		if b.Len() > maxLineSize {
+			return "", errLongLine
+		}
+		if !isPrefix {
+			return b.String(), nil
+		}
+	}
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:banner error", map[string]interface{}{
+		"address": p.address,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return "probe:banner:" + p.address
+}
+
+func parseScript(l []string) ([]step, error) {
+	if len(l) == 0 {
+		return nil, errEmptyScript
+	}
+	script := make([]step, 0, len(l))
+	for _, s := range l {
+		cmd, arg, _ := strings.Cut(s, " ")
+		switch cmd {
+		case "send":
+			if strings.ContainsAny(arg, "\r\n") {
+				return nil, fmt.Errorf("invalid step: %q", s)
+			}
+			script = append(script, step{send: arg})
+		case "expect":
+			re, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, err
+			}
+			script = append(script, step{expect: re})
+		default:
+			return nil, fmt.Errorf("invalid step: %q", s)
+		}
+	}
+	return script, nil
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := goma.GetStringList("script", params)
+	if err != nil {
+		return nil, err
+	}
+	script, err := parseScript(l)
+	if err != nil {
+		return nil, err
+	}
+
+	useTLS, err := goma.GetBool("tls", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	serverName, err := goma.GetString("server_name", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		serverName = host
+	default:
+		return nil, err
+	}
+	insecure, err := goma.GetBool("insecure_skip_verify", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig = &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: insecure,
+		}
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		if mode != modeLatency && mode != modeStatus {
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeLatency
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultLatencyErrval
+		if mode == modeStatus {
+			errval = defaultStatusErrval
+		}
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:   address,
+		script:    script,
+		tlsConfig: tlsConfig,
+		mode:      mode,
+		errval:    errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("banner", construct)
+}
@@ -0,0 +1,176 @@
+package banner
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+// serveIMAP emulates an IMAP server that accepts only LOGOUT.
+func serveIMAP(c net.Conn) {
+	io.WriteString(c, "* OK IMAP4rev1 ready\r\n")
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if strings.ToUpper(cmd) == "LOGOUT" {
+			io.WriteString(c, "* BYE logging out\r\n"+tag+" OK LOGOUT completed\r\n")
+			return
+		}
+		io.WriteString(c, tag+" BAD unknown command\r\n")
+	}
+}
+
+func testServer(t *testing.T, handler func(net.Conn)) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	badScripts := [][]string{
+		{},
+		{"expect ("},
+		{"read ^OK"},
+		{"send a\r\nb"},
+	}
+	for _, s := range badScripts {
+		if _, err := construct(map[string]interface{}{
+			"address": "localhost:143",
+			"script":  s,
+		}); err == nil {
+			t.Error("invalid script should be rejected:", s)
+		}
+	}
+
+	p, err := construct(map[string]interface{}{
+		"address": "localhost:143",
+		"script":  []string{"expect ^\\* OK", "send a1 LOGOUT", "expect ^a1 OK"},
+		"mode":    "status",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.(*probe).script) != 3 {
+		t.Error(`len(p.(*probe).script) != 3`)
+	}
+	if p.(*probe).script[1].send != "a1 LOGOUT" {
+		t.Error(`p.(*probe).script[1].send != "a1 LOGOUT"`)
+	}
+	if !goma.FloatEquals(p.(*probe).errval, 1) {
+		t.Error(`!goma.FloatEquals(p.(*probe).errval, 1)`)
+	}
+}
+
+func TestScript(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, serveIMAP)
+	cases := []struct {
+		script   []string
+		expected float64
+	}{
+		{[]string{"expect ^\\* OK"}, 0},
+		{[]string{"expect ^\\* OK", "send a1 LOGOUT", "expect ^a1 OK"}, 0},
+		{[]string{"expect ^\\* OK", "send a1 NOOP", "expect ^a1 OK"}, 1},
+		{[]string{"expect ^\\+OK"}, 1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, map[string]interface{}{
+			"address": addr,
+			"script":  c.script,
+			"mode":    "status",
+		})
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.script, f, "!=", c.expected)
+		}
+	}
+
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+		"script":  []string{"expect ^\\* OK", "send a1 LOGOUT", "expect ^a1 OK"},
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, func(c net.Conn) {
+		io.WriteString(c, "-ERR service unavailable\r\n")
+	})
+	start := time.Now()
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+		"script":  []string{"expect ^\\+OK"},
+		"errval":  100.0,
+	})
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("probe should fail when the connection is closed")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, func(c net.Conn) {
+		time.Sleep(10 * time.Second)
+	})
+	start := time.Now()
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+		"script":  []string{"expect ^\\* OK"},
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
@@ -0,0 +1,21 @@
+/*
+Package disk implements "disk" probe type that checks the disk usage
+of a file system by statfs(2).
+
+The value of the probe is determined by mode:
+
+	Mode   Value
+	used   The used ratio between 0.0 and 1.0, computed like df(1).
+	free   Bytes available to unprivileged users.
+
+If statfs fails, errval is returned.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	path      string             Mount point or a file in the file system.
+	                             Required.
+	mode      string   used      "used" or "free".
+	errval    float64  -1        Return value upon an error.
+*/
+package disk
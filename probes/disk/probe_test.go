@@ -0,0 +1,73 @@
+package disk
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes/internal/statfs"
+)
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"path": "/",
+		"mode": "total",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+}
+
+func TestDisk(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	st, err := statfs.Get(dir)
+	if err != nil {
+		t.Skip("statfs is not available:", err)
+	}
+
+	p, err := construct(map[string]interface{}{
+		"path": dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f < 0 || f > 1 {
+		t.Error(`unexpected used ratio:`, f)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"path": dir,
+		"mode": "free",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if f < 0 || f > float64(st.Size) {
+		t.Error(`unexpected free bytes:`, f)
+	}
+	// other processes may consume the space.
+	if f > float64(st.Avail)*1.1+1 {
+		t.Error(`unexpected free bytes:`, f, st.Avail)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"path":   filepath.Join(dir, "nosuchdir"),
+		"errval": 100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+}
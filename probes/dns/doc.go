@@ -0,0 +1,41 @@
+/*
+Package dns implements "dns" probe type that queries DNS servers.
+
+The probe sends a query for name and type to server, then returns
+a value determined by mode:
+
+	Mode      Value
+	latency   The time in seconds taken to receive the response.
+	count     The number of answer records of the queried type.
+	match     0 if the answers equal the expected set, otherwise 1.
+
+For "match", answers are compared with expect ignoring the order.
+Answers are represented as follows:
+
+	Type          Representation
+	A, AAAA       IP address, e.g. "192.0.2.1"
+	CNAME, NS     Domain name without the trailing dot, e.g. "www.example.com"
+	PTR           Same as CNAME
+	MX            Mail exchange host name
+	SRV           Target and port, e.g. "sip.example.com:5060"
+	TXT           Concatenated strings
+
+Domain names are compared case-insensitively.
+
+If the response is truncated, the query is retried over TCP.
+errval is returned upon network errors or if the response code is
+neither NOERROR nor NXDOMAIN.  NXDOMAIN responses have no answers.
+
+The constructor takes these parameters:
+
+	Name     Type      Default   Description
+	server   string              host:port of the DNS server.  Required.
+	                             The port defaults to 53.
+	name     string              Domain name to query.  Required.
+	type     string    A         One of the types listed above.
+	mode     string    latency   One of "latency", "count", or "match".
+	expect   []string            Expected answers.  Required for "match".
+	tcp      bool      false     If true, query over TCP.
+	errval   float64   -1        Return value upon an error.
+*/
+package dns
@@ -0,0 +1,383 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	modeLatency = "latency"
+	modeCount   = "count"
+	modeMatch   = "match"
+
+	defaultPort   = "53"
+	defaultType   = "A"
+	defaultErrval = -1.0
+
+	maxUDPSize = 1232
+)
+
+var (
+	errBadRcode = errors.New("bad response code")
+	errMismatch = errors.New("response does not match the query")
+
+	types = map[string]dnsmessage.Type{
+		"A":     dnsmessage.TypeA,
+		"AAAA":  dnsmessage.TypeAAAA,
+		"CNAME": dnsmessage.TypeCNAME,
+		"NS":    dnsmessage.TypeNS,
+		"PTR":   dnsmessage.TypePTR,
+		"MX":    dnsmessage.TypeMX,
+		"SRV":   dnsmessage.TypeSRV,
+		"TXT":   dnsmessage.TypeTXT,
+	}
+)
+
+type probe struct {
+	server string
+	name   dnsmessage.Name
+	qtype  dnsmessage.Type
+	mode   string
+	expect []string
+	tcp    bool
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	msg, err := p.query(ctx, p.tcp)
+	if err == nil && msg.Truncated && !p.tcp {
+		msg, err = p.query(ctx, true)
+	}
+	if err != nil {
+		return p.fail(err)
+	}
+	elapsed := time.Since(start)
+
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return p.fail(fmt.Errorf("%v: %s", errBadRcode, msg.RCode))
+	}
+
+	switch p.mode {
+	case modeCount:
+		return float64(len(p.answers(msg)))
+	case modeMatch:
+		if p.match(p.answers(msg)) {
+			return 0
+		}
+		return 1
+	}
+	return elapsed.Seconds()
+}
+
+func (p *probe) query(ctx context.Context, useTCP bool) (*dnsmessage.Message, error) {
+	id := uint16(rand.Intn(0x10000))
+	q := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               id,
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  p.name,
+			Type:  p.qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	b, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	network := "udp"
+	if useTCP {
+		network = "tcp"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, p.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	if useTCP {
+		return p.exchangeTCP(conn, b, id)
+	}
+	return p.exchangeUDP(conn, b, id)
+}
+
+func (p *probe) exchangeUDP(conn net.Conn, b []byte, id uint16) (*dnsmessage.Message, error) {
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, maxUDPSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		msg := new(dnsmessage.Message)
+		if err := msg.Unpack(buf[:n]); err != nil {
+			// ignore garbage
+			continue
+		}
+		if !msg.Response || msg.ID != id {
+			continue
+		}
+		if err := p.checkQuestion(msg); err != nil {
+			// may be a spoofed or stray response; keep waiting.
+			continue
+		}
+		return msg, nil
+	}
+}
+
+func (p *probe) exchangeTCP(conn net.Conn, b []byte, id uint16) (*dnsmessage.Message, error) {
+	req := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(req, uint16(len(b)))
+	copy(req[2:], b)
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var l [2]byte
+	if _, err := io.ReadFull(conn, l[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	msg := new(dnsmessage.Message)
+	if err := msg.Unpack(buf); err != nil {
+		return nil, err
+	}
+	if !msg.Response || msg.ID != id {
+		return nil, errMismatch
+	}
+	if err := p.checkQuestion(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (p *probe) checkQuestion(msg *dnsmessage.Message) error {
+	if len(msg.Questions) != 1 {
+		return errMismatch
+	}
+	q := msg.Questions[0]
+	if q.Type != p.qtype || !strings.EqualFold(q.Name.String(), p.name.String()) {
+		return errMismatch
+	}
+	return nil
+}
+
+// answers returns string representations of answer records of the
+// queried type.
+func (p *probe) answers(msg *dnsmessage.Message) []string {
+	var l []string
+	for _, rr := range msg.Answers {
+		if rr.Header.Type != p.qtype {
+			continue
+		}
+		switch b := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			l = append(l, net.IP(b.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			l = append(l, net.IP(b.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			l = append(l, normalizeName(b.CNAME.String()))
+		case *dnsmessage.NSResource:
+			l = append(l, normalizeName(b.NS.String()))
+		case *dnsmessage.PTRResource:
+			l = append(l, normalizeName(b.PTR.String()))
+		case *dnsmessage.MXResource:
+			l = append(l, normalizeName(b.MX.String()))
+		case *dnsmessage.SRVResource:
+			port := strconv.Itoa(int(b.Port))
+			l = append(l, net.JoinHostPort(normalizeName(b.Target.String()), port))
+		case *dnsmessage.TXTResource:
+			l = append(l, strings.Join(b.TXT, ""))
+		}
+	}
+	return l
+}
+
+func (p *probe) match(answers []string) bool {
+	if len(answers) != len(p.expect) {
+		return false
+	}
+	sort.Strings(answers)
+	for i, a := range answers {
+		if a != p.expect[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:dns error", map[string]interface{}{
+		"server": p.server,
+		"name":   p.name.String(),
+		"error":  err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:dns:%s:%s:%s", p.server, p.name, p.qtype)
+}
+
+// normalizeName returns a lower-case domain name without the trailing dot.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// normalizeExpect returns the representation of an expected answer
+// in the same form as probe.answers.
+func normalizeExpect(qtype dnsmessage.Type, s string) (string, error) {
+	switch qtype {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP address: %s", s)
+		}
+		return ip.String(), nil
+	case dnsmessage.TypeSRV:
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return "", err
+		}
+		return net.JoinHostPort(normalizeName(host), port), nil
+	case dnsmessage.TypeTXT:
+		return s, nil
+	}
+	return normalizeName(s), nil
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	server, err := goma.GetString("server", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, defaultPort)
+	}
+
+	nameString, err := goma.GetString("name", params)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(nameString, ".") {
+		nameString += "."
+	}
+	name, err := dnsmessage.NewName(nameString)
+	if err != nil {
+		return nil, err
+	}
+
+	typeString, err := goma.GetString("type", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		typeString = defaultType
+	default:
+		return nil, err
+	}
+	qtype, ok := types[strings.ToUpper(typeString)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type: %s", typeString)
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeLatency, modeCount, modeMatch:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeLatency
+	default:
+		return nil, err
+	}
+
+	expectList, err := goma.GetStringList("expect", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		if mode == modeMatch {
+			return nil, errors.New("expect is required for match mode")
+		}
+	default:
+		return nil, err
+	}
+	expect := make([]string, len(expectList))
+	for i, s := range expectList {
+		expect[i], err = normalizeExpect(qtype, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(expect)
+
+	useTCP, err := goma.GetBool("tcp", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		server: server,
+		name:   name,
+		qtype:  qtype,
+		mode:   mode,
+		expect: expect,
+		tcp:    useTCP,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("dns", construct)
+}
@@ -0,0 +1,341 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var testServer string
+
+// answer returns a response for a query.
+//
+//	www.example.com.   A     192.0.2.1, 192.0.2.2
+//	www.example.com.   TXT   "hello goma"
+//	big.example.com.   A     20 records (truncated over UDP)
+//	stray.example.com. A     192.0.2.3 (after a stray response over UDP)
+//	slow.example.com.  -     no response
+//	others             -     NXDOMAIN
+func answer(q *dnsmessage.Message, overTCP bool) *dnsmessage.Message {
+	qs := q.Questions[0]
+	name := strings.ToLower(qs.Name.String())
+	resp := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:            q.ID,
+			Response:      true,
+			Authoritative: true,
+		},
+		Questions: q.Questions,
+	}
+	hdr := dnsmessage.ResourceHeader{
+		Name:  qs.Name,
+		Type:  qs.Type,
+		Class: dnsmessage.ClassINET,
+		TTL:   60,
+	}
+	addA := func(a [4]byte) {
+		resp.Answers = append(resp.Answers, dnsmessage.Resource{
+			Header: hdr,
+			Body:   &dnsmessage.AResource{A: a},
+		})
+	}
+
+	switch {
+	case name == "www.example.com." && qs.Type == dnsmessage.TypeA:
+		addA([4]byte{192, 0, 2, 2})
+		addA([4]byte{192, 0, 2, 1})
+	case name == "www.example.com." && qs.Type == dnsmessage.TypeTXT:
+		resp.Answers = append(resp.Answers, dnsmessage.Resource{
+			Header: hdr,
+			Body:   &dnsmessage.TXTResource{TXT: []string{"hello ", "goma"}},
+		})
+	case name == "www.example.com.":
+	case name == "big.example.com.":
+		if !overTCP {
+			resp.Truncated = true
+			break
+		}
+		for i := 0; i < 20; i++ {
+			addA([4]byte{192, 0, 2, byte(i)})
+		}
+	case name == "stray.example.com." && qs.Type == dnsmessage.TypeA:
+		addA([4]byte{192, 0, 2, 3})
+	case name == "slow.example.com.":
+		return nil
+	default:
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+	return resp
+}
+
+func serveUDP(conn net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var q dnsmessage.Message
+		if err := q.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		resp := answer(&q, false)
+		if resp == nil {
+			continue
+		}
+		if strings.EqualFold(q.Questions[0].Name.String(), "stray.example.com.") {
+			// a response with the same ID for another question.
+			stray := *resp
+			stray.Questions = []dnsmessage.Question{{
+				Name:  dnsmessage.MustNewName("www.example.com."),
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET,
+			}}
+			b, err := stray.Pack()
+			if err != nil {
+				log.Fatal(err)
+			}
+			conn.WriteTo(b, addr)
+		}
+		b, err := resp.Pack()
+		if err != nil {
+			log.Fatal(err)
+		}
+		conn.WriteTo(b, addr)
+	}
+}
+
+func serveTCP(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			var hdr [2]byte
+			if _, err := io.ReadFull(c, hdr[:]); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(hdr[:]))
+			if _, err := io.ReadFull(c, buf); err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(buf); err != nil {
+				return
+			}
+			resp := answer(&q, true)
+			if resp == nil {
+				time.Sleep(10 * time.Second)
+				return
+			}
+			b, err := resp.Pack()
+			if err != nil {
+				log.Fatal(err)
+			}
+			binary.BigEndian.PutUint16(hdr[:], uint16(len(b)))
+			c.Write(append(hdr[:], b...))
+		}(conn)
+	}
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	testServer = l.Addr().String()
+	conn, err := net.ListenPacket("udp", testServer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go serveTCP(l)
+	go serveUDP(conn)
+	os.Exit(m.Run())
+}
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 1*time.Second)
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	params["server"] = testServer
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"server": testServer,
+		"name":   "www.example.com",
+		"type":   "HINFO",
+	}); err == nil {
+		t.Error("unsupported type should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"server": testServer,
+		"name":   "www.example.com",
+		"mode":   "match",
+	}); err == nil {
+		t.Error("match mode without expect should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"server": testServer,
+		"name":   "www.example.com",
+		"mode":   "match",
+		"expect": []interface{}{"not an address"},
+	}); err == nil {
+		t.Error("invalid address should be rejected")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"server": "127.0.0.1",
+		"name":   "www.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).server != "127.0.0.1:53" {
+		t.Error(`p.(*probe).server != "127.0.0.1:53"`)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+
+	f := testProbe(t, map[string]interface{}{
+		"name": "www.example.com",
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name": "www.example.com",
+		"tcp":  true,
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency over TCP:`, f)
+	}
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	f := testProbe(t, map[string]interface{}{
+		"name": "www.example.com",
+		"mode": "count",
+	})
+	if !goma.FloatEquals(f, 2) {
+		t.Error(`!goma.FloatEquals(f, 2)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name": "www.example.com",
+		"type": "AAAA",
+		"mode": "count",
+	})
+	if !goma.FloatEquals(f, 0) {
+		t.Error(`!goma.FloatEquals(f, 0)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name": "nx.example.com",
+		"mode": "count",
+	})
+	if !goma.FloatEquals(f, 0) {
+		t.Error(`NXDOMAIN: !goma.FloatEquals(f, 0)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name": "big.example.com",
+		"mode": "count",
+	})
+	if !goma.FloatEquals(f, 20) {
+		t.Error(`truncated: !goma.FloatEquals(f, 20)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name": "stray.example.com",
+		"mode": "count",
+	})
+	if !goma.FloatEquals(f, 1) {
+		t.Error(`stray: !goma.FloatEquals(f, 1)`, f)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	f := testProbe(t, map[string]interface{}{
+		"name":   "WWW.example.com.",
+		"mode":   "match",
+		"expect": []interface{}{"192.0.2.1", "192.0.2.2"},
+	})
+	if !goma.FloatEquals(f, 0) {
+		t.Error(`!goma.FloatEquals(f, 0)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name":   "www.example.com",
+		"mode":   "match",
+		"expect": []interface{}{"192.0.2.1"},
+	})
+	if !goma.FloatEquals(f, 1) {
+		t.Error(`!goma.FloatEquals(f, 1)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"name":   "www.example.com",
+		"type":   "txt",
+		"mode":   "match",
+		"expect": []interface{}{"hello goma"},
+	})
+	if !goma.FloatEquals(f, 0) {
+		t.Error(`TXT: !goma.FloatEquals(f, 0)`, f)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	for _, useTCP := range []bool{false, true} {
+		start := time.Now()
+		f := testProbe(t, map[string]interface{}{
+			"name":   "slow.example.com",
+			"tcp":    useTCP,
+			"errval": 100.0,
+		})
+		if !goma.FloatEquals(f, 100.0) {
+			t.Error(`!goma.FloatEquals(f, 100.0)`)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Error("probe does not respect the deadline")
+		}
+	}
+}
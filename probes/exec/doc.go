@@ -7,14 +7,35 @@ or 1.0 if command timed out or does not exit normally.
 If parse is true, the command output (stdout) will be interpreted
 as a floating point number, and will be used as the probe value.
 
+If format is "nagios", command is run as a Nagios plugin.  The value
+of the probe will be the exit status: 0 (OK), 1 (WARNING), 2 (CRITICAL),
+or 3 (UNKNOWN).  A monitor with "warn_max = 0" and "max = 1" reports
+WARNING as a warning, and others as critical failures.  If metric is
+given, the value of the performance data labeled metric will be the
+probe value instead, e.g. 0.5 for "load1=0.5;1;2" with metric "load1".
+If command timed out, exits with other status, or the performance data
+is not found, errval is returned.
+
+If format is "json", the command output will be decoded as JSON, and
+the value selected by json_path will be the probe value.  json_path is
+a subset of JSONPath such as "$.queue.depth" or "$.items[0]['size']".
+Numbers, numeric strings, and booleans (true is 1) can be selected.
+If command fails or the value is not found, errval is returned.
+
 The constructor takes these parameters:
 
 	Name       Type      Default   Description
 	command    string              The command to run.
 	args       []string      nil   Command arguments.
 	parse      bool        false   See the above description.
-	errval     float64         0   When parse is true and command failed,
-	                               this value is returned as the probe value.
+	format     string              "nagios" or "json".  Optional.
+	metric     string              Label of Nagios performance data.
+	json_path  string          $   JSONPath to select the value.
+	errval     float64       (*)   When parse is true or format is given
+	                               and command failed, this value is
+	                               returned as the probe value.
 	env        []string      nil   Environment variables.  See os.Environ.
+
+(*) errval defaults to 3 for "nagios" format, and 0 otherwise.
 */
 package exec
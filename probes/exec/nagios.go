@@ -0,0 +1,105 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// nagiosUnknown is the exit status of Nagios plugins for UNKNOWN.
+	nagiosUnknown = 3
+
+	// uomChars are characters of units of measurement in perfdata.
+	uomChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ%"
+)
+
+var (
+	errInvalidPerfdata = errors.New("invalid perfdata")
+	errNoPerfdata      = errors.New("perfdata not found")
+)
+
+// perfdataText collects performance data from the output of a Nagios
+// plugin.  Performance data follow "|" in the first line, and "|" in
+// a line of the long text continues to the end of the output.
+func perfdataText(output string) string {
+	lines := strings.Split(output, "\n")
+
+	var b strings.Builder
+	if _, perf, ok := strings.Cut(lines[0], "|"); ok {
+		b.WriteString(perf)
+	}
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			b.WriteByte(' ')
+			b.WriteString(line)
+			continue
+		}
+		if _, perf, ok := strings.Cut(line, "|"); ok {
+			b.WriteByte(' ')
+			b.WriteString(perf)
+			inPerf = true
+		}
+	}
+	return b.String()
+}
+
+// perfValue returns the value of the performance data for label.
+//
+// Performance data are space-separated 'label'=value[UOM];warn;crit;min;max.
+// Labels may be quoted by single quotes, and a quote in a quoted label
+// is written as two quotes.
+func perfValue(output, label string) (float64, error) {
+	s := strings.TrimSpace(perfdataText(output))
+	for len(s) > 0 {
+		var l string
+		if s[0] == '\'' {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s); i++ {
+				if s[i] != '\'' {
+					b.WriteByte(s[i])
+					continue
+				}
+				if i+1 < len(s) && s[i+1] == '\'' {
+					b.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			if i+1 >= len(s) || s[i+1] != '=' {
+				return 0, errInvalidPerfdata
+			}
+			l = b.String()
+			s = s[i+2:]
+		} else {
+			i := strings.IndexByte(s, '=')
+			if i <= 0 {
+				return 0, errInvalidPerfdata
+			}
+			l = s[:i]
+			s = s[i+1:]
+		}
+
+		data := s
+		if i := strings.IndexAny(s, " \t\r\n"); i >= 0 {
+			data, s = s[:i], strings.TrimSpace(s[i:])
+		} else {
+			s = ""
+		}
+		if l != label {
+			continue
+		}
+
+		value, _, _ := strings.Cut(data, ";")
+		f, err := strconv.ParseFloat(strings.TrimRight(value, uomChars), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%v: %s=%s", errInvalidPerfdata, l, data)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%v: %s", errNoPerfdata, label)
+}
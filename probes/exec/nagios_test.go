@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/cybozu-go/goma"
+)
+
+func TestPerfValue(t *testing.T) {
+	t.Parallel()
+
+	output := "DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968 'free space'=56%;;\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | /boot=68MB;88;93;0;98\n" +
+		"/home=69357MB;253404;253409;0;253414 'it''s'=1.5s time=U;;\n"
+
+	cases := []struct {
+		label    string
+		expected float64
+	}{
+		{"/", 2643},
+		{"free space", 56},
+		{"/boot", 68},
+		{"/home", 69357},
+		{"it's", 1.5},
+	}
+	for _, c := range cases {
+		f, err := perfValue(output, c.label)
+		if err != nil {
+			t.Error(c.label, err)
+			continue
+		}
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.label, f, "!=", c.expected)
+		}
+	}
+
+	for _, label := range []string{"time", "free", "nosuchlabel"} {
+		if _, err := perfValue(output, label); err == nil {
+			t.Error("perfValue should fail for", label)
+		}
+	}
+
+	if _, err := perfValue("OK - no perfdata\n", "load1"); err == nil {
+		t.Error("perfValue should fail without perfdata")
+	}
+	if _, err := perfValue("OK | 'load1=1", "load1"); err == nil {
+		t.Error("perfValue should fail for unterminated quote")
+	}
+	f, err := perfValue("OK | load1=0.5;1;2 load5=-0.25", "load5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !goma.FloatEquals(f, -0.25) {
+		t.Error(`!goma.FloatEquals(f, -0.25)`)
+	}
+}
@@ -2,6 +2,8 @@ package exec
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"sort"
@@ -10,15 +12,24 @@ import (
 
 	"github.com/cybozu-go/goma"
 	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/goma/probes/internal/jsonpath"
 	"github.com/cybozu-go/log"
 )
 
+const (
+	formatNagios = "nagios"
+	formatJSON   = "json"
+)
+
 type probe struct {
-	command string
-	args    []string
-	parse   bool
-	errval  float64
-	env     []string
+	command  string
+	args     []string
+	parse    bool
+	format   string
+	metric   string
+	jsonPath jsonpath.Path
+	errval   float64
+	env      []string
 }
 
 func (p *probe) Probe(ctx context.Context) float64 {
@@ -28,18 +39,26 @@ func (p *probe) Probe(ctx context.Context) float64 {
 	}
 
 	data, err := cmd.Output()
+	if p.format == formatNagios {
+		return p.nagios(data, err)
+	}
 	if err != nil {
-		log.Error("probe:exec error", map[string]interface{}{
-			"command": p.command,
-			"args":    p.args,
-			"error":   err.Error(),
-		})
-		if p.parse {
+		p.logError(err)
+		if p.parse || p.format == formatJSON {
 			return p.errval
 		}
 		return 1.0
 	}
 
+	if p.format == formatJSON {
+		f, err := p.jsonPath.Extract(data)
+		if err != nil {
+			p.logError(err)
+			return p.errval
+		}
+		return f
+	}
+
 	if p.parse {
 		f, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
 		if err != nil {
@@ -51,6 +70,37 @@ func (p *probe) Probe(ctx context.Context) float64 {
 	return 0
 }
 
+// nagios interprets the result of a Nagios plugin.
+func (p *probe) nagios(data []byte, err error) float64 {
+	status := 0
+	if err != nil {
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) || ee.ExitCode() < 0 || ee.ExitCode() > nagiosUnknown {
+			p.logError(err)
+			return p.errval
+		}
+		status = ee.ExitCode()
+	}
+
+	if len(p.metric) == 0 {
+		return float64(status)
+	}
+	f, err := perfValue(string(data), p.metric)
+	if err != nil {
+		p.logError(err)
+		return p.errval
+	}
+	return f
+}
+
+func (p *probe) logError(err error) {
+	log.Error("probe:exec error", map[string]interface{}{
+		"command": p.command,
+		"args":    p.args,
+		"error":   err.Error(),
+	})
+}
+
 func (p *probe) String() string {
 	return "probe:exec:" + p.command
 }
@@ -83,8 +133,56 @@ func construct(params map[string]interface{}) (probes.Prober, error) {
 	if err != nil && err != goma.ErrNoKey {
 		return nil, err
 	}
+
+	format, err := goma.GetString("format", params)
+	switch err {
+	case nil:
+		if format != formatNagios && format != formatJSON {
+			return nil, fmt.Errorf("invalid format: %s", format)
+		}
+		if parse {
+			return nil, errors.New("parse and format are exclusive")
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	metric, err := goma.GetString("metric", params)
+	switch err {
+	case nil:
+		if format != formatNagios {
+			return nil, errors.New("metric needs nagios format")
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	var path jsonpath.Path
+	switch s, err := goma.GetString("json_path", params); err {
+	case nil:
+		if format != formatJSON {
+			return nil, errors.New("json_path needs json format")
+		}
+		path, err = jsonpath.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+		path = jsonpath.Path{}
+	default:
+		return nil, err
+	}
+
 	errval, err := goma.GetFloat("errval", params)
-	if err != nil && err != goma.ErrNoKey {
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		if format == formatNagios {
+			errval = nagiosUnknown
+		}
+	default:
 		return nil, err
 	}
 	env, err := goma.GetStringList("env", params)
@@ -96,11 +194,14 @@ func construct(params map[string]interface{}) (probes.Prober, error) {
 	}
 
 	return &probe{
-		command: command,
-		args:    args,
-		parse:   parse,
-		errval:  errval,
-		env:     env,
+		command:  command,
+		args:     args,
+		parse:    parse,
+		format:   format,
+		metric:   metric,
+		jsonPath: path,
+		errval:   errval,
+		env:      env,
 	}, nil
 }
 
@@ -127,3 +127,111 @@ func TestProbeTimeout(t *testing.T) {
 		t.Error(`!goma.FloatEquals(f, 1.0)`)
 	}
 }
+
+func TestConstructFormat(t *testing.T) {
+	t.Parallel()
+
+	badParams := []map[string]interface{}{
+		{"command": "true", "format": "xml"},
+		{"command": "true", "format": "json", "parse": true},
+		{"command": "true", "metric": "load1"},
+		{"command": "true", "format": "json", "metric": "load1"},
+		{"command": "true", "json_path": "$.a"},
+		{"command": "true", "format": "json", "json_path": "a"},
+	}
+	for _, params := range badParams {
+		if _, err := construct(params); err == nil {
+			t.Error("should be rejected:", params)
+		}
+	}
+}
+
+func TestProbeNagios(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		script   string
+		metric   string
+		expected float64
+	}{
+		{"echo 'OK - load average: 0.50 | load1=0.5;1;2'", "", 0},
+		{"echo 'WARNING - load average: 1.50 | load1=1.5;1;2'; exit 1", "", 1},
+		{"echo 'CRITICAL - load average: 2.50 | load1=2.5;1;2'; exit 2", "", 2},
+		{"echo 'UNKNOWN - no data'; exit 3", "", 3},
+		{"exit 4", "", 3},
+		{"echo 'WARNING - load average: 1.50 | load1=1.5;1;2'; exit 1", "load1", 1.5},
+		{"echo 'OK - load average: 0.50 | load1=0.5;1;2'", "load5", 3},
+		{"echo 'CRITICAL - no perfdata'; exit 2", "load1", 3},
+	}
+	for _, c := range cases {
+		params := map[string]interface{}{
+			"command": "sh",
+			"args":    []interface{}{"-c", c.script},
+			"format":  "nagios",
+		}
+		if len(c.metric) > 0 {
+			params["metric"] = c.metric
+		}
+		p, err := construct(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.script, c.metric, f, "!=", c.expected)
+		}
+	}
+
+	p, err := construct(map[string]interface{}{
+		"command": "sleep",
+		"args":    []interface{}{"10"},
+		"format":  "nagios",
+		"errval":  -1.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, -1.0) {
+		t.Error(`!goma.FloatEquals(f, -1.0)`)
+	}
+}
+
+func TestProbeJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		script   string
+		path     string
+		expected float64
+	}{
+		{`echo '{"queue": {"depth": 12}}'`, "$.queue.depth", 12},
+		{`echo '{"items": [{"size": "3.5"}]}'`, "$.items[0]['size']", 3.5},
+		{`echo '{"ok": true}'`, "$.ok", 1},
+		{`echo '42'`, "", 42},
+		{`echo '{"queue": {}}'`, "$.queue.depth", 9},
+		{`echo 'not json'`, "$.queue.depth", 9},
+		{`echo '{"queue": {"depth": 12}}'; exit 1`, "$.queue.depth", 9},
+	}
+	for _, c := range cases {
+		params := map[string]interface{}{
+			"command": "sh",
+			"args":    []interface{}{"-c", c.script},
+			"format":  "json",
+			"errval":  9.0,
+		}
+		if len(c.path) > 0 {
+			params["json_path"] = c.path
+		}
+		p, err := construct(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.script, c.path, f, "!=", c.expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Package file implements "file" probe type that checks the freshness
+and the content of a file.
+
+path may be a glob pattern such as "/var/backup/*.tar.gz".  In that
+case, the newest file among the matches is checked.
+See https://golang.org/pkg/path/filepath/#Match for the syntax.
+
+The value of the probe is determined by mode:
+
+	Mode    Value
+	age     Seconds since the last modification.
+	size    Size in bytes.
+	lines   The number of lines.
+	value   A number parsed from the file.
+
+For "value", the content of the file is parsed as a floating point
+number.  If pattern is given, the last line matching the regular
+expression is searched instead, and the captured string is parsed.
+The capture group named "value" is used if exists, or the first group.
+
+If no file matches path, or the value cannot be parsed, errval is
+returned.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	path      string             File path or glob pattern.  Required.
+	mode      string   age       One of the modes listed above.
+	pattern   string             Regexp for "value" mode.  Optional.
+	errval    float64  -1        Return value upon an error.
+*/
+package file
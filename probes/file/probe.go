@@ -0,0 +1,232 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeAge   = "age"
+	modeSize  = "size"
+	modeLines = "lines"
+	modeValue = "value"
+
+	defaultErrval = -1.0
+
+	// maxValueSize limits the size of a file parsed as a number.
+	maxValueSize = 64 * 1024
+
+	// maxLineSize limits the size of a line matched with pattern.
+	maxLineSize = 1024 * 1024
+)
+
+var (
+	errNoFile     = errors.New("no file matches")
+	errNotMatched = errors.New("no line matches")
+	errNoCapture  = errors.New("pattern needs a capture group")
+)
+
+type probe struct {
+	path    string
+	mode    string
+	pattern *regexp.Regexp
+	errval  float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	v, err := p.probe()
+	if err != nil {
+		log.Error("probe:file error", map[string]interface{}{
+			"path":  p.path,
+			"error": err.Error(),
+		})
+		return p.errval
+	}
+	return v
+}
+
+func (p *probe) probe() (float64, error) {
+	name, fi, err := newest(p.path)
+	if err != nil {
+		return 0, err
+	}
+
+	switch p.mode {
+	case modeAge:
+		return time.Since(fi.ModTime()).Seconds(), nil
+	case modeSize:
+		return float64(fi.Size()), nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if p.mode == modeLines {
+		n, err := countLines(f)
+		return float64(n), err
+	}
+	if p.pattern != nil {
+		return p.lastMatch(f)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxValueSize))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
+// newest returns the most recently modified file matching pattern.
+func newest(pattern string) (string, os.FileInfo, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var name string
+	var newest os.FileInfo
+	for _, m := range matches {
+		fi, err := os.Stat(m)
+		if err != nil {
+			// the file may have been removed after Glob.
+			continue
+		}
+		if newest == nil || fi.ModTime().After(newest.ModTime()) {
+			name, newest = m, fi
+		}
+	}
+	if newest == nil {
+		return "", nil, fmt.Errorf("%v: %s", errNoFile, pattern)
+	}
+	return name, newest, nil
+}
+
+// countLines counts lines including the last line without newline.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 32*1024)
+	n := 0
+	last := byte('\n')
+	for {
+		c, err := r.Read(buf)
+		if c > 0 {
+			n += bytes.Count(buf[:c], []byte{'\n'})
+			last = buf[c-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		n++
+	}
+	return n, nil
+}
+
+// lastMatch parses the captured string of the last line matching p.pattern.
+func (p *probe) lastMatch(r io.Reader) (float64, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 4096), maxLineSize)
+	var m []string
+	for s.Scan() {
+		if mm := p.pattern.FindStringSubmatch(s.Text()); mm != nil {
+			m = mm
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	if m == nil {
+		return 0, errNotMatched
+	}
+
+	i := p.pattern.SubexpIndex("value")
+	if i == -1 {
+		i = 1
+	}
+	return strconv.ParseFloat(strings.TrimSpace(m[i]), 64)
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:file:%s:%s", p.path, p.mode)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	path, err := goma.GetString("path", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := filepath.Match(path, ""); err != nil {
+		return nil, err
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeAge, modeSize, modeLines, modeValue:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeAge
+	default:
+		return nil, err
+	}
+
+	var pattern *regexp.Regexp
+	switch s, err := goma.GetString("pattern", params); err {
+	case nil:
+		if mode != modeValue {
+			return nil, errors.New("pattern needs value mode")
+		}
+		pattern, err = regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.NumSubexp() == 0 {
+			return nil, errNoCapture
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		path:    path,
+		mode:    mode,
+		pattern: pattern,
+		errval:  errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("file", construct)
+}
@@ -0,0 +1,155 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+func writeFile(t *testing.T, name, data string, age time.Duration) {
+	t.Helper()
+
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(name, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Probe(context.Background())
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	badParams := []map[string]interface{}{
+		{"path": "/tmp/[", "mode": "age"},
+		{"path": "/tmp/a", "mode": "mtime"},
+		{"path": "/tmp/a", "mode": "size", "pattern": "(.*)"},
+		{"path": "/tmp/a", "mode": "value", "pattern": "("},
+		{"path": "/tmp/a", "mode": "value", "pattern": "^done"},
+	}
+	for _, params := range badParams {
+		if _, err := construct(params); err == nil {
+			t.Error("should be rejected:", params)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "backup-1.tar"), "old", 3*time.Hour)
+	writeFile(t, filepath.Join(dir, "backup-2.tar"), "newest", time.Hour)
+	writeFile(t, filepath.Join(dir, "backup-3.tar"), "older", 2*time.Hour)
+
+	f := testProbe(t, map[string]interface{}{
+		"path": filepath.Join(dir, "backup-1.tar"),
+	})
+	if f < 3*3600 || f > 3*3600+60 {
+		t.Error(`unexpected age:`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"path": filepath.Join(dir, "backup-*.tar"),
+	})
+	if f < 3600 || f > 3600+60 {
+		t.Error(`unexpected age:`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"path": filepath.Join(dir, "backup-*.tar"),
+		"mode": "size",
+	})
+	if !goma.FloatEquals(f, 6) {
+		t.Error(`!goma.FloatEquals(f, 6)`)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"path":   filepath.Join(dir, "nosuch-*.tar"),
+		"errval": 100.0,
+	})
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+}
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cases := []struct {
+		data     string
+		expected float64
+	}{
+		{"", 0},
+		{"a\n", 1},
+		{"a\nb\nc\n", 3},
+		{"a\nb\nc", 3},
+		{"\n\n", 2},
+	}
+	for i, c := range cases {
+		name := filepath.Join(dir, "lines"+string(rune('0'+i)))
+		writeFile(t, name, c.data, 0)
+		f := testProbe(t, map[string]interface{}{
+			"path": name,
+			"mode": "lines",
+		})
+		if !goma.FloatEquals(f, c.expected) {
+			t.Errorf("%q: %g != %g", c.data, f, c.expected)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "number"), " 12.5\n", 0)
+	writeFile(t, filepath.Join(dir, "text"), "hello\n", 0)
+	writeFile(t, filepath.Join(dir, "job.log"),
+		"start\nprocessed 10 items\nprocessed 20 items in 3.5 seconds\nend\n", 0)
+
+	cases := []struct {
+		name     string
+		pattern  string
+		expected float64
+	}{
+		{"number", "", 12.5},
+		{"text", "", -1},
+		{"job.log", `processed (\d+) items`, 20},
+		{"job.log", `in (?P<value>[0-9.]+) seconds`, 3.5},
+		{"job.log", `failed (\d+)`, -1},
+		{"job.log", `(start|end)`, -1},
+	}
+	for _, c := range cases {
+		params := map[string]interface{}{
+			"path": filepath.Join(dir, c.name),
+			"mode": "value",
+		}
+		if len(c.pattern) > 0 {
+			params["pattern"] = c.pattern
+		}
+		f := testProbe(t, params)
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.name, c.pattern, f, "!=", c.expected)
+		}
+	}
+}
@@ -5,21 +5,73 @@ The value of the probe will be 0 if HTTP server responds with status
 between 200 and 299, or 1.0 for other status values, connection errors,
 or timeouts.
 
+If measure is "latency", the value will be the time in seconds taken
+to receive the whole response.  If measure is "ttfb", the value will be
+the time in seconds until the first byte of the response is received.
+For these, errval is returned upon failures.
+
+If expect_status is given, the status must be one of the list
+instead of 2xx.  If expect_body is given, the response body must match
+the regular expression.  If expect_header is given, the response must
+have the headers, and one of the values of each header must match the
+regular expression.  An empty expression only checks the presence.
+Unexpected responses are treated as errors.
+
 If parse is true, the response body will be interpreted
 as a floating point number, and will be used as the probe value.
 
-Basic authentication can be used by embedding user:password in url.
+If json_path is given, the response body is decoded as JSON and
+the value selected by json_path will be the probe value.  json_path is
+a subset of JSONPath such as "$.queue.depth" or "$.items[0]['size']".
+Numbers, numeric strings, and booleans (true is 1) can be selected.
+
+If extract is given, the response body is searched for the regular
+expression, and the captured string will be parsed as the probe value.
+The capture group named "value" is used if exists, or the first group.
+
+json_path and extract imply parse.
+
+Basic authentication can be used by embedding user:password in url,
+or by user and password parameters.
+
+Redirects are followed by default.  If follow_redirects is false,
+the redirect response itself is checked.
+
+If unix_socket is given, the probe connects to the socket instead of
+the host in url.  url is still used for the path and Host header.
+proxy is not used in this case.
+
+TLS client certificates can be given by cert_file and key_file.
+Server certificates are verified with the system roots, or with
+the certificates in ca_file if given.
 
 The constructor takes these parameters:
 
-	Name       Type     Default   Description
-	url        string             URL to test HTTP server.
-	method     string   GET       Method to use.
-	agent      string   goma/0.1  User-Agent string.
-	proxy      string             URL for proxy server.  Optional.
-	header     map[string]string  HTTP headers.  Optional.
-	parse      bool     false     See the above description.
-	errval     float64  0         When parse is true and command failed,
-	                              this value is returned as the probe value.
+	Name                  Type               Default   Description
+	url                   string                       URL to test HTTP server.
+	method                string             GET       Method to use.
+	agent                 string             goma/0.1  User-Agent string.
+	proxy                 string                       URL for proxy server.  Optional.
+	header                map[string]string            HTTP headers.  Optional.
+	body                  string                       Request body.  Optional.
+	user                  string                       User name for basic authentication.
+	password              string                       Password for basic authentication.
+	follow_redirects      bool               true      If false, do not follow redirects.
+	ca_file               string                       PEM file of CA certificates.  Optional.
+	cert_file             string                       PEM file of client certificate.  Optional.
+	key_file              string                       PEM file of client private key.  Optional.
+	insecure_skip_verify  bool               false     If true, skip TLS certificate checks.
+	unix_socket           string                       Path of Unix domain socket.  Optional.
+	expect_status         []int                        Expected status codes.  Optional.
+	expect_body           string                       Regexp for the body.  Optional.
+	expect_header         map[string]string            Regexps for headers.  Optional.
+	parse                 bool               false     See the above description.
+	json_path             string                       JSONPath to select the value.  Optional.
+	extract               string                       Regexp to capture the value.  Optional.
+	measure               string             status    One of "status", "latency", or "ttfb".
+	errval                float64            (*)       When parse is true or measure is not "status",
+	                                                   and the probe failed, this value is returned.
+
+(*) errval defaults to -1 if measure is "latency" or "ttfb", and 0 otherwise.
 */
 package http
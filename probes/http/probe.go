@@ -2,74 +2,115 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cybozu-go/goma"
 	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/goma/probes/internal/jsonpath"
 	"github.com/cybozu-go/log"
 )
 
+var (
+	errBodyNotMatched = errors.New("body does not match")
+	errNoCapture      = errors.New("extract needs a capture group")
+)
+
+const (
+	measureStatus  = "status"
+	measureLatency = "latency"
+	measureTTFB    = "ttfb"
+
+	defaultMeasureErrval = -1.0
+)
+
 type probe struct {
-	client *http.Client
-	url    *url.URL
-	method string
-	header map[string]string
-	parse  bool
-	errval float64
+	client   *http.Client
+	url      *url.URL
+	method   string
+	header   map[string]string
+	body     string
+	user     string
+	password string
+	parse    bool
+	measure  string
+	errval   float64
+
+	expectStatus []int
+	expectBody   *regexp.Regexp
+	expectHeader map[string]*regexp.Regexp
+	jsonPath     jsonpath.Path
+	extract      *regexp.Regexp
 }
 
 func (p *probe) Probe(ctx context.Context) float64 {
-	header := make(http.Header)
-	for k, v := range p.header {
-		header.Set(k, v)
+	var ttfb time.Duration
+	start := time.Now()
+	if p.measure == measureTTFB {
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotFirstResponseByte: func() {
+				// updated for each redirect
+				ttfb = time.Since(start)
+			},
+		})
 	}
 
-	req := &http.Request{
-		Method:     p.method,
-		URL:        p.url,
-		Proto:      "HTTP/1.1",
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Header:     header,
-		Host:       p.url.Host,
+	var body io.Reader
+	if len(p.body) > 0 {
+		body = strings.NewReader(p.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url.String(), body)
+	if err != nil {
+		return p.fail(err)
+	}
+	for k, v := range p.header {
+		req.Header.Set(k, v)
+	}
+	if len(p.user) > 0 {
+		req.SetBasicAuth(p.user, p.password)
 	}
 
-	resp, err := p.client.Do(req.WithContext(ctx))
+	resp, err := p.client.Do(req)
 	if err != nil {
-		log.Error("probe:http error", map[string]interface{}{
-			"url":   p.url.String(),
-			"error": err.Error(),
-		})
-		if p.parse {
-			return p.errval
-		}
-		return 1.0
+		return p.fail(err)
 	}
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		if p.parse {
-			return p.errval
-		}
-		return 1.0
+		return p.fail(err)
 	}
+	total := time.Since(start)
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if p.parse {
-			return p.errval
-		}
-		return 1.0
+	if err := p.check(resp, data); err != nil {
+		log.Error("probe:http unexpected response", map[string]interface{}{
+			"url":   p.url.String(),
+			"error": err.Error(),
+		})
+		return p.failValue()
+	}
+
+	switch p.measure {
+	case measureLatency:
+		return total.Seconds()
+	case measureTTFB:
+		return ttfb.Seconds()
 	}
 
 	if p.parse {
-		f, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		f, err := p.value(data)
 		if err != nil {
 			log.Error("probe:http parsing failure", map[string]interface{}{
 				"url":   p.url.String(),
@@ -82,6 +123,82 @@ func (p *probe) Probe(ctx context.Context) float64 {
 	return 0
 }
 
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:http error", map[string]interface{}{
+		"url":   p.url.String(),
+		"error": err.Error(),
+	})
+	return p.failValue()
+}
+
+// failValue returns the probe value for failures.
+func (p *probe) failValue() float64 {
+	if p.parse || p.measure != measureStatus {
+		return p.errval
+	}
+	return 1.0
+}
+
+// check tests the response against expectations.
+func (p *probe) check(resp *http.Response, data []byte) error {
+	if !p.checkStatus(resp.StatusCode) {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	for k, re := range p.expectHeader {
+		values := resp.Header.Values(k)
+		if len(values) == 0 {
+			return fmt.Errorf("no header: %s", k)
+		}
+		matched := false
+		for _, v := range values {
+			if re.MatchString(v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("header does not match: %s", k)
+		}
+	}
+
+	if p.expectBody != nil && !p.expectBody.Match(data) {
+		return errBodyNotMatched
+	}
+	return nil
+}
+
+func (p *probe) checkStatus(status int) bool {
+	if len(p.expectStatus) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, s := range p.expectStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// value extracts the probe value from the response body.
+func (p *probe) value(data []byte) (float64, error) {
+	switch {
+	case p.jsonPath != nil:
+		return p.jsonPath.Extract(data)
+	case p.extract != nil:
+		m := p.extract.FindSubmatch(data)
+		if m == nil {
+			return 0, errBodyNotMatched
+		}
+		i := p.extract.SubexpIndex("value")
+		if i == -1 {
+			i = 1
+		}
+		return strconv.ParseFloat(strings.TrimSpace(string(m[i])), 64)
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
+
 func (p *probe) String() string {
 	return "probe:http:" + p.url.String()
 }
@@ -135,7 +252,127 @@ func construct(params map[string]interface{}) (probes.Prober, error) {
 	if err != nil && err != goma.ErrNoKey {
 		return nil, err
 	}
+	expectStatus, err := goma.GetIntList("expect_status", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	var expectBody *regexp.Regexp
+	switch s, err := goma.GetString("expect_body", params); err {
+	case nil:
+		expectBody, err = regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	expectHeader := make(map[string]*regexp.Regexp)
+	switch hm, err := goma.GetStringMap("expect_header", params); err {
+	case nil:
+		for k, v := range hm {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				return nil, err
+			}
+			expectHeader[k] = re
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	var path jsonpath.Path
+	switch s, err := goma.GetString("json_path", params); err {
+	case nil:
+		path, err = jsonpath.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		parse = true
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	var extract *regexp.Regexp
+	switch s, err := goma.GetString("extract", params); err {
+	case nil:
+		if path != nil {
+			return nil, errors.New("json_path and extract are exclusive")
+		}
+		extract, err = regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		if extract.NumSubexp() == 0 {
+			return nil, errNoCapture
+		}
+		parse = true
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	body, err := goma.GetString("body", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	user, err := goma.GetString("user", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	password, err := goma.GetString("password", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	measure, err := goma.GetString("measure", params)
+	switch err {
+	case nil:
+		switch measure {
+		case measureStatus:
+		case measureLatency, measureTTFB:
+			if parse {
+				return nil, errors.New("measure cannot be used with parse, json_path, or extract")
+			}
+		default:
+			return nil, fmt.Errorf("invalid measure: %s", measure)
+		}
+	case goma.ErrNoKey:
+		measure = measureStatus
+	default:
+		return nil, err
+	}
+
 	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		if measure != measureStatus {
+			errval = defaultMeasureErrval
+		}
+	default:
+		return nil, err
+	}
+
+	followRedirects := true
+	switch b, err := goma.GetBool("follow_redirects", params); err {
+	case nil:
+		followRedirects = b
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	unixSocket, err := goma.GetString("unix_socket", params)
 	if err != nil && err != goma.ErrNoKey {
 		return nil, err
 	}
@@ -146,24 +383,107 @@ func construct(params map[string]interface{}) (probes.Prober, error) {
 			Timeout:   30 * time.Second,
 			KeepAlive: 30 * time.Second,
 		}).Dial,
+		TLSClientConfig:       tlsConfig,
 		TLSHandshakeTimeout:   10 * time.Second,
 		MaxIdleConnsPerHost:   1,
 		ExpectContinueTimeout: 500 * time.Millisecond,
 	}
+	if len(unixSocket) > 0 {
+		d := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.Proxy = nil
+		transport.Dial = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", unixSocket)
+		}
+	}
 	client := &http.Client{
 		Transport: transport,
 	}
+	if !followRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
 
 	return &probe{
-		client: client,
-		url:    u,
-		method: method,
-		header: header,
-		parse:  parse,
-		errval: errval,
+		client:   client,
+		url:      u,
+		method:   method,
+		header:   header,
+		body:     body,
+		user:     user,
+		password: password,
+		parse:    parse,
+		measure:  measure,
+		errval:   errval,
+
+		expectStatus: expectStatus,
+		expectBody:   expectBody,
+		expectHeader: expectHeader,
+		jsonPath:     path,
+		extract:      extract,
 	}, nil
 }
 
+// newTLSConfig creates *tls.Config from TLS related parameters.
+// nil is returned if no such parameters are given.
+func newTLSConfig(params map[string]interface{}) (*tls.Config, error) {
+	var c *tls.Config
+	config := func() *tls.Config {
+		if c == nil {
+			c = new(tls.Config)
+		}
+		return c
+	}
+
+	switch caFile, err := goma.GetString("ca_file", params); err {
+	case nil:
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no CA certificate found", caFile)
+		}
+		config().RootCAs = pool
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	certFile, err := goma.GetString("cert_file", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	keyFile, err := goma.GetString("key_file", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config().Certificates = []tls.Certificate{cert}
+	}
+
+	switch insecure, err := goma.GetBool("insecure_skip_verify", params); err {
+	case nil:
+		if insecure {
+			config().InsecureSkipVerify = true
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	return c, nil
+}
+
 func init() {
 	probes.Register("http", construct)
 }
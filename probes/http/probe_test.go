@@ -2,12 +2,23 @@ package http
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -49,6 +60,35 @@ func serve(l net.Listener) {
 			http.Error(w, "Bad User Agent", http.StatusBadRequest)
 		}
 	})
+	router.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(testHeaderName, testHeaderValue)
+		w.Write([]byte(`{"status": "ok", "queue": {"depth": 12.5, "items": [3, "4"]}}`))
+	})
+	router.HandleFunc("/201", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	router.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "goma" || password != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}
+	})
+	router.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		if string(data) != "hello" {
+			http.Error(w, "Bad body", http.StatusBadRequest)
+		}
+	})
+	router.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/200", http.StatusFound)
+	})
+	router.HandleFunc("/slowbody", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
 	router.HandleFunc("/sleep/", func(w http.ResponseWriter, r *http.Request) {
 		t := strings.Split(r.URL.Path, "/")
 		i, err := strconv.Atoi(t[len(t)-1])
@@ -278,3 +318,517 @@ func TestTimeout(t *testing.T) {
 		t.Error(`!goma.FloatEquals(f, 1.0)`)
 	}
 }
+
+func TestExpectStatus(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url":           getURL("500"),
+		"expect_status": []interface{}{int64(500), int64(503)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":           getURL("201"),
+		"expect_status": []interface{}{int64(200)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+}
+
+func TestExpectBody(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url":         getURL("json"),
+		"expect_body": `"status":\s*"ok"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":         getURL("json"),
+		"expect_body": `"status":\s*"ng"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+}
+
+func TestExpectHeader(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url": getURL("json"),
+		"expect_header": map[string]interface{}{
+			"content-type": "^application/json",
+			testHeaderName: "",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url": getURL("200"),
+		"expect_header": map[string]interface{}{
+			testHeaderName: "",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`missing header: !goma.FloatEquals(f, 1.0)`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url": getURL("json"),
+		"expect_header": map[string]interface{}{
+			"Content-Type": "^text/",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]float64{
+		"$.queue.depth":       12.5,
+		"$['queue']['depth']": 12.5,
+		"$.queue.items[0]":    3,
+		"$.queue.items[1]":    4,
+		"$.queue.items[2]":    100,
+		"$.status":            100,
+		"$.nosuchkey":         100,
+	}
+	for path, expected := range cases {
+		p, err := construct(map[string]interface{}{
+			"url":       getURL("json"),
+			"json_path": path,
+			"errval":    100.0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if !goma.FloatEquals(f, expected) {
+			t.Error(path, f, "!=", expected)
+		}
+	}
+
+	if _, err := construct(map[string]interface{}{
+		"url":       getURL("json"),
+		"json_path": "queue.depth",
+	}); err == nil {
+		t.Error("invalid json_path should be rejected")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url":     getURL("json"),
+		"extract": `"depth":\s*([0-9.]+)`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if !goma.FloatEquals(f, 12.5) {
+		t.Error(`!goma.FloatEquals(f, 12.5)`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":     getURL("json"),
+		"extract": `"(items)": \[(?P<value>\d+)`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 3) {
+		t.Error(`named group: !goma.FloatEquals(f, 3)`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":     getURL("500"),
+		"extract": `(\d+)`,
+		"errval":  100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+
+	if _, err := construct(map[string]interface{}{
+		"url":     getURL("json"),
+		"extract": `depth`,
+	}); err == nil {
+		t.Error("extract without capture group should be rejected")
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url":      getURL("auth"),
+		"user":     "goma",
+		"password": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url": getURL("auth"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+}
+
+func TestBody(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url":    getURL("body"),
+		"method": "POST",
+		"body":   "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"url": getURL("redirect"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":              getURL("redirect"),
+		"follow_redirects": false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":              getURL("redirect"),
+		"follow_redirects": false,
+		"expect_status":    []interface{}{int64(302)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(map[string]interface{}{
+		"url":     getURL("slowbody"),
+		"measure": "size",
+	}); err == nil {
+		t.Error("invalid measure should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"url":     getURL("slowbody"),
+		"measure": "latency",
+		"parse":   true,
+	}); err == nil {
+		t.Error("measure and parse should be exclusive")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"url":     getURL("slowbody"),
+		"measure": "latency",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f < 0.3 || f > 5 {
+		t.Error(`unexpected latency:`, f)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":     getURL("slowbody"),
+		"measure": "ttfb",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if f < 0 || f >= 0.3 {
+		t.Error(`unexpected time to first byte:`, f)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":     getURL("500"),
+		"measure": "latency",
+		"errval":  100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+
+	// errval defaults to -1 for latency so that failures exceed no max.
+	for _, measure := range []string{"latency", "ttfb"} {
+		p, err = construct(map[string]interface{}{
+			"url":     getURL("500"),
+			"measure": measure,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f = p.Probe(context.Background())
+		if !goma.FloatEquals(f, -1) {
+			t.Error(measure, `!goma.FloatEquals(f, -1)`)
+		}
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+	p, err = construct(map[string]interface{}{
+		"url":     "http://" + closed + "/",
+		"measure": "latency",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, -1) {
+		t.Error("connection errors should return -1:", f)
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "goma"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "goma" {
+			http.Error(w, "no client certificate", http.StatusForbidden)
+		}
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	s.StartTLS()
+	defer s.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := writeClientCert(t, dir)
+
+	p, err := construct(map[string]interface{}{
+		"url":       s.URL,
+		"cert_file": certFile,
+		"key_file":  keyFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error("untrusted certificate should be rejected")
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":       s.URL,
+		"ca_file":   caFile,
+		"cert_file": certFile,
+		"key_file":  keyFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":                  s.URL,
+		"insecure_skip_verify": true,
+		"cert_file":            certFile,
+		"key_file":             keyFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`insecure: f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":     s.URL,
+		"ca_file": caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error("request without client certificate should fail")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "goma.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Host != "goma.local" || r.URL.Path != "/health" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+			}
+		}),
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	p, err := construct(map[string]interface{}{
+		"url":         "http://goma.local/health",
+		"unix_socket": sock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"url":         "http://goma.local/none",
+		"unix_socket": sock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 1.0) {
+		t.Error(`!goma.FloatEquals(f, 1.0)`)
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Package inode implements "inode" probe type that checks the inode usage
+of a file system by statfs(2).
+
+The value of the probe is determined by mode:
+
+	Mode   Value
+	used   The used ratio of inodes between 0.0 and 1.0.
+	free   The number of free inodes.
+
+File systems without a fixed number of inodes, such as btrfs,
+report 0 for "used".  If statfs fails, errval is returned.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	path      string             Mount point or a file in the file system.
+	                             Required.
+	mode      string   used      "used" or "free".
+	errval    float64  -1        Return value upon an error.
+*/
+package inode
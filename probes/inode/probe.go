@@ -0,0 +1,87 @@
+package inode
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/goma/probes/internal/statfs"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeUsed = "used"
+	modeFree = "free"
+
+	defaultErrval = -1.0
+)
+
+type probe struct {
+	path   string
+	mode   string
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	st, err := statfs.Get(p.path)
+	if err != nil {
+		log.Error("probe:inode error", map[string]interface{}{
+			"path":  p.path,
+			"error": err.Error(),
+		})
+		return p.errval
+	}
+
+	if p.mode == modeFree {
+		return float64(st.FreeFiles)
+	}
+	if st.Files == 0 {
+		return 0
+	}
+	return float64(st.Files-st.FreeFiles) / float64(st.Files)
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:inode:%s:%s", p.path, p.mode)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	path, err := goma.GetString("path", params)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeUsed, modeFree:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeUsed
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		path:   path,
+		mode:   mode,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("inode", construct)
+}
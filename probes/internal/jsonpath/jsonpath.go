@@ -0,0 +1,116 @@
+// Package jsonpath implements a subset of JSONPath to take numbers
+// from JSON data for probes.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidPath = errors.New("invalid JSON path")
+	errNotFound    = errors.New("no value found for JSON path")
+	errNotNumber   = errors.New("JSON value is not a number")
+)
+
+// Path is a subset of JSONPath to select a value.
+//
+// The expression begins with "$", followed by member names like
+// ".name" or "['name']", and array indices like "[0]".
+type Path []interface{}
+
+// Parse parses a JSONPath expression.
+func Parse(expr string) (Path, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("%v: %s", errInvalidPath, expr)
+	}
+
+	var path Path
+	s := expr[1:]
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			n := strings.IndexAny(s, ".[")
+			if n == -1 {
+				n = len(s)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("%v: %s", errInvalidPath, expr)
+			}
+			path = append(path, s[:n])
+			s = s[n:]
+		case '[':
+			n := strings.IndexByte(s, ']')
+			if n == -1 {
+				return nil, fmt.Errorf("%v: %s", errInvalidPath, expr)
+			}
+			elem := s[1:n]
+			s = s[n+1:]
+			if len(elem) >= 2 && (elem[0] == '\'' || elem[0] == '"') && elem[len(elem)-1] == elem[0] {
+				path = append(path, elem[1:len(elem)-1])
+				continue
+			}
+			i, err := strconv.Atoi(elem)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("%v: %s", errInvalidPath, expr)
+			}
+			path = append(path, i)
+		default:
+			return nil, fmt.Errorf("%v: %s", errInvalidPath, expr)
+		}
+	}
+	return path, nil
+}
+
+// Extract decodes JSON data and returns the selected value as float64.
+// Numbers, numeric strings, and booleans (true is 1) are accepted.
+func (p Path) Extract(data []byte) (float64, error) {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return 0, err
+	}
+
+	for _, elem := range p {
+		switch elem := elem.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return 0, errNotFound
+			}
+			v, ok = m[elem]
+			if !ok {
+				return 0, errNotFound
+			}
+		case int:
+			l, ok := v.([]interface{})
+			if !ok || elem >= len(l) {
+				return 0, errNotFound
+			}
+			v = l[elem]
+		}
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, errNotNumber
+		}
+		return f, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, errNotNumber
+}
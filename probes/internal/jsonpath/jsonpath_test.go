@@ -0,0 +1,133 @@
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		expr     string
+		expected Path
+	}{
+		{"$", nil},
+		{"$.queue", Path{"queue"}},
+		{"$.queue.depth", Path{"queue", "depth"}},
+		{"$['queue'][\"depth\"]", Path{"queue", "depth"}},
+		{"$['a.b']", Path{"a.b"}},
+		{"$.items[0]", Path{"items", 0}},
+		{"$[1][2].size", Path{1, 2, "size"}},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.expr)
+		if err != nil {
+			t.Error(c.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(p, c.expected) {
+			t.Errorf("%s: %#v != %#v", c.expr, p, c.expected)
+		}
+	}
+
+	badExprs := []string{
+		"",
+		"queue.depth",
+		"$.",
+		"$..depth",
+		"$queue",
+		"$[0",
+		"$[-1]",
+		"$[x]",
+		"$['queue]",
+	}
+	for _, expr := range badExprs {
+		if _, err := Parse(expr); err == nil {
+			t.Error("invalid path should be rejected:", expr)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{
+		"queue": {"depth": 12.5, "items": [3, 4]},
+		"count": "42",
+		"ok": true,
+		"ng": false,
+		"name": "goma",
+		"nothing": null,
+		"big": 12345678901234567890
+	}`)
+
+	cases := []struct {
+		expr     string
+		expected float64
+	}{
+		{"$.queue.depth", 12.5},
+		{"$['queue']['depth']", 12.5},
+		{"$.queue.items[0]", 3},
+		{"$.queue.items[1]", 4},
+		{"$.count", 42},
+		{"$.ok", 1},
+		{"$.ng", 0},
+		{"$.big", 12345678901234567890},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := p.Extract(data)
+		if err != nil {
+			t.Error(c.expr, err)
+			continue
+		}
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.expr, f, "!=", c.expected)
+		}
+	}
+
+	errCases := []struct {
+		expr string
+		err  error
+	}{
+		{"$.nosuchkey", errNotFound},
+		{"$.queue.items[2]", errNotFound},
+		{"$.queue[0]", errNotFound},
+		{"$.queue.items.depth", errNotFound},
+		{"$.count.value", errNotFound},
+		{"$.name", errNotNumber},
+		{"$.nothing", errNotNumber},
+		{"$.queue", errNotNumber},
+		{"$.queue.items", errNotNumber},
+	}
+	for _, c := range errCases {
+		p, err := Parse(c.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Extract(data); err != c.err {
+			t.Error(c.expr, err, "!=", c.err)
+		}
+	}
+
+	p, err := Parse("$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := p.Extract([]byte(" 3.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !goma.FloatEquals(f, 3.5) {
+		t.Error(`!goma.FloatEquals(f, 3.5)`)
+	}
+	if _, err := p.Extract([]byte("{")); err == nil {
+		t.Error("broken JSON should be rejected")
+	}
+}
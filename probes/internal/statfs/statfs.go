@@ -0,0 +1,16 @@
+// Package statfs provides file system statistics for probes.
+package statfs
+
+// Stat is statistics of a file system.
+type Stat struct {
+	// Size is the size of the file system in bytes.
+	Size uint64
+	// Used is the number of bytes used.
+	Used uint64
+	// Avail is the number of bytes available to unprivileged users.
+	Avail uint64
+	// Files is the total number of inodes.
+	Files uint64
+	// FreeFiles is the number of free inodes.
+	FreeFiles uint64
+}
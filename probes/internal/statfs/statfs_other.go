@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package statfs
+
+import "errors"
+
+// Get returns statistics of the file system containing path.
+func Get(path string) (*Stat, error) {
+	return nil, errors.New("statfs is not supported")
+}
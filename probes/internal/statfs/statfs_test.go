@@ -0,0 +1,26 @@
+package statfs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	st, err := Get(dir)
+	if err != nil {
+		t.Skip("statfs is not available:", err)
+	}
+	if st.Used > st.Size || st.Avail > st.Size {
+		t.Error("unexpected usage:", st)
+	}
+	if st.FreeFiles > st.Files {
+		t.Error("unexpected inodes:", st)
+	}
+
+	if _, err := Get(filepath.Join(dir, "nosuchdir")); err == nil {
+		t.Error("non-existent path should be an error")
+	}
+}
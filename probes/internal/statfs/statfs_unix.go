@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+
+package statfs
+
+import "syscall"
+
+// Get returns statistics of the file system containing path.
+func Get(path string) (*Stat, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, err
+	}
+	bsize := uint64(st.Bsize)
+	return &Stat{
+		Size:      uint64(st.Blocks) * bsize,
+		Used:      (uint64(st.Blocks) - uint64(st.Bfree)) * bsize,
+		Avail:     uint64(st.Bavail) * bsize,
+		Files:     uint64(st.Files),
+		FreeFiles: uint64(st.Ffree),
+	}, nil
+}
@@ -0,0 +1,18 @@
+/*
+Package loadavg implements "loadavg" probe type that reads the system
+load average from /proc/loadavg.
+
+The value of the probe will be the load average of the period.
+If per_cpu is true, the value is divided by the number of processors
+counted in /proc/cpuinfo.  If the files cannot be read, errval is
+returned.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	period    int      1         1, 5, or 15 minutes.
+	per_cpu   bool     false     If true, divide by the number of CPUs.
+	proc      string   /proc     Mount point of procfs.
+	errval    float64  -1        Return value upon an error.
+*/
+package loadavg
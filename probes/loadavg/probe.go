@@ -0,0 +1,147 @@
+package loadavg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultPeriod = 1
+	defaultProc   = "/proc"
+	defaultErrval = -1.0
+)
+
+var (
+	errInvalidLoadavg = errors.New("invalid loadavg")
+	errNoCPU          = errors.New("no processor found")
+
+	// field index in /proc/loadavg for each period.
+	periodIndex = map[int]int{1: 0, 5: 1, 15: 2}
+)
+
+type probe struct {
+	period int
+	perCPU bool
+	proc   string
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	v, err := p.loadavg()
+	if err != nil {
+		return p.fail(err)
+	}
+	if !p.perCPU {
+		return v
+	}
+
+	n, err := p.numCPU()
+	if err != nil {
+		return p.fail(err)
+	}
+	return v / float64(n)
+}
+
+func (p *probe) loadavg() (float64, error) {
+	data, err := os.ReadFile(filepath.Join(p.proc, "loadavg"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return 0, errInvalidLoadavg
+	}
+	return strconv.ParseFloat(fields[periodIndex[p.period]], 64)
+}
+
+// numCPU counts "processor" lines in cpuinfo.
+func (p *probe) numCPU() (int, error) {
+	data, err := os.ReadFile(filepath.Join(p.proc, "cpuinfo"))
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, _, ok := strings.Cut(sc.Text(), ":")
+		if ok && strings.TrimSpace(key) == "processor" {
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, errNoCPU
+	}
+	return n, nil
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:loadavg error", map[string]interface{}{
+		"proc":  p.proc,
+		"error": err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:loadavg:%d", p.period)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	period, err := goma.GetInt("period", params)
+	switch err {
+	case nil:
+		if _, ok := periodIndex[period]; !ok {
+			return nil, fmt.Errorf("invalid period: %d", period)
+		}
+	case goma.ErrNoKey:
+		period = defaultPeriod
+	default:
+		return nil, err
+	}
+
+	perCPU, err := goma.GetBool("per_cpu", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	proc, err := goma.GetString("proc", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		proc = defaultProc
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		period: period,
+		perCPU: perCPU,
+		proc:   proc,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("loadavg", construct)
+}
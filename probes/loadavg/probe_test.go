@@ -0,0 +1,67 @@
+package loadavg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+)
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Probe(context.Background())
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(map[string]interface{}{
+		"period": 10,
+	}); err == nil {
+		t.Error("invalid period should be rejected")
+	}
+
+	p, err := construct(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).proc != "/proc" {
+		t.Error(`p.(*probe).proc != "/proc"`)
+	}
+}
+
+func TestLoadavg(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]float64{1: 0.52, 5: 1.25, 15: 2.00}
+	for period, expected := range cases {
+		f := testProbe(t, map[string]interface{}{
+			"period": period,
+			"proc":   "testdata",
+		})
+		if !goma.FloatEquals(f, expected) {
+			t.Error(period, f, "!=", expected)
+		}
+	}
+
+	f := testProbe(t, map[string]interface{}{
+		"period":  15,
+		"per_cpu": true,
+		"proc":    "testdata",
+	})
+	if !goma.FloatEquals(f, 0.5) {
+		t.Error(`!goma.FloatEquals(f, 0.5)`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"proc": "nosuchdir",
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Package logmatch implements "logmatch" probe type that counts lines
+matching a regular expression in log files.
+
+The value of the probe will be the number of lines appended to the
+files since the previous probe and matching pattern.  With "min = 0"
+and "max = 0", a monitor fails when any line matches.
+
+path may be a glob pattern such as "/var/log/app/*.log" to read
+multiple files.  See https://golang.org/pkg/path/filepath/#Match for
+the syntax.  If no file matches, the value is 0.
+
+The probe remembers the read offset of each file between probes.
+At the first probe, existing contents are skipped.  Files found at
+later probes are read from the beginning.
+
+Files are identified by their inodes.  If a file is replaced by
+another file as log rotation does, or truncated, the file is read from
+the beginning.  If path also matches rotated files, e.g.
+"/var/log/app.log*", a renamed file is read from the previous offset.
+Otherwise, lines appended to the old file after the previous probe
+are not counted.
+
+A line without the trailing newline is not counted until the newline
+is written.  Lines longer than 1 MiB are matched only by the first
+1 MiB.
+
+If the probe fails to read a file, errval is returned.  The offsets of
+files read before the failure are advanced, so matches in them are
+not counted at the next probe.  Other offsets are kept unchanged.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	path      string             File path or glob pattern.  Required.
+	pattern   string             Regexp for lines.  Required.
+	errval    float64  -1        Return value upon an error.
+*/
+package logmatch
@@ -0,0 +1,206 @@
+package logmatch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultErrval = -1.0
+
+	// maxLineSize limits the size of a line to be matched.
+	maxLineSize = 1024 * 1024
+
+	// checkInterval is the number of lines between checks of ctx.
+	checkInterval = 1000
+)
+
+// position is the read position of a file.
+type position struct {
+	fi     os.FileInfo
+	offset int64
+}
+
+type probe struct {
+	path    string
+	pattern *regexp.Regexp
+	errval  float64
+
+	lock        sync.Mutex
+	initialized bool
+	positions   map[string]position
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// Glob fails only for bad patterns, which are rejected by construct.
+	matches, _ := filepath.Glob(p.path)
+
+	count := 0
+	positions := make(map[string]position)
+	for _, name := range matches {
+		fi, err := os.Stat(name)
+		if err != nil {
+			// the file may have been removed after Glob.
+			continue
+		}
+		if fi.IsDir() {
+			continue
+		}
+
+		pos, ok := p.lookup(name, fi)
+		switch {
+		case !ok && !p.initialized:
+			// skip existing contents at the first probe.
+			positions[name] = position{fi, fi.Size()}
+			continue
+		case !ok, fi.Size() < pos.offset:
+			// new or truncated file.
+			pos.offset = 0
+		}
+
+		n, offset, err := p.scan(ctx, name, pos.offset)
+		if err != nil {
+			log.Error("probe:logmatch error", map[string]interface{}{
+				"path":  name,
+				"error": err.Error(),
+			})
+			// keep positions of files already read.
+			for name, pos := range p.positions {
+				if _, ok := positions[name]; !ok {
+					positions[name] = pos
+				}
+			}
+			p.positions = positions
+			return p.errval
+		}
+		count += n
+		positions[name] = position{fi, offset}
+	}
+
+	p.positions = positions
+	p.initialized = true
+	return float64(count)
+}
+
+// lookup finds the previous position of the file.
+// The file may have been renamed by log rotation.
+func (p *probe) lookup(name string, fi os.FileInfo) (position, bool) {
+	if pos, ok := p.positions[name]; ok && os.SameFile(pos.fi, fi) {
+		return pos, true
+	}
+	for _, pos := range p.positions {
+		if os.SameFile(pos.fi, fi) {
+			return pos, true
+		}
+	}
+	return position{}, false
+}
+
+// scan counts lines matching p.pattern from offset of the file.
+// It returns the count and the offset next to the last complete line.
+func (p *probe) scan(ctx context.Context, name string, offset int64) (int, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	count := 0
+	var line []byte
+	var size int64
+	for lines := 1; ; lines++ {
+		if lines%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		chunk, err := r.ReadSlice('\n')
+		size += int64(len(chunk))
+		if len(line) < maxLineSize {
+			line = append(line, chunk...)
+		}
+		switch err {
+		case nil:
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			// incomplete line is read again at the next probe.
+			return count, offset, nil
+		default:
+			return 0, 0, err
+		}
+
+		if len(line) > maxLineSize {
+			line = line[:maxLineSize]
+		}
+		if p.pattern.Match(bytes.TrimRight(line, "\r\n")) {
+			count++
+		}
+		offset += size
+		line = line[:0]
+		size = 0
+	}
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:logmatch:%s:%s", p.path, p.pattern)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	path, err := goma.GetString("path", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := filepath.Match(path, ""); err != nil {
+		return nil, err
+	}
+
+	s, err := goma.GetString("pattern", params)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := regexp.Compile(s)
+	if err != nil {
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		path:      path,
+		pattern:   pattern,
+		errval:    errval,
+		positions: make(map[string]position),
+	}, nil
+}
+
+func init() {
+	probes.Register("logmatch", construct)
+}
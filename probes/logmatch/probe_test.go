@@ -0,0 +1,249 @@
+package logmatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+)
+
+func appendFile(t *testing.T, name, data string) {
+	t.Helper()
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testProbe(t *testing.T, p probes.Prober, expected float64) {
+	t.Helper()
+
+	f := p.Probe(context.Background())
+	if !goma.FloatEquals(f, expected) {
+		t.Error(f, "!=", expected)
+	}
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"path": "/var/log/app.log",
+	}); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"path":    "/var/log/[",
+		"pattern": "ERROR",
+	}); err == nil {
+		t.Error("bad glob should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"path":    "/var/log/app.log",
+		"pattern": "(",
+	}); err == nil {
+		t.Error("bad regexp should be rejected")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, name, "ERROR old\n")
+
+	p, err := construct(map[string]interface{}{
+		"path":    name,
+		"pattern": "^ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// existing contents are skipped.
+	testProbe(t, p, 0)
+
+	appendFile(t, name, "INFO a\nERROR b\nERROR c\n")
+	testProbe(t, p, 2)
+	testProbe(t, p, 0)
+
+	// incomplete lines are counted after completion.
+	appendFile(t, name, "ERROR d\r\nERR")
+	testProbe(t, p, 1)
+	appendFile(t, name, "OR e\n")
+	testProbe(t, p, 1)
+
+	// $ matches the end of a line without newline.
+	p2, err := construct(map[string]interface{}{
+		"path":    name,
+		"pattern": "e$",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p2, 0)
+	appendFile(t, name, "ERROR e\r\n")
+	testProbe(t, p2, 1)
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	appendFile(t, name, "ERROR old\n")
+
+	p, err := construct(map[string]interface{}{
+		"path":    name,
+		"pattern": "ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+
+	// truncation
+	appendFile(t, name, "ERROR a\n")
+	if err := os.Truncate(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name, "ERROR b\n")
+	testProbe(t, p, 1)
+
+	// rotation
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name, "ERROR c\nERROR d\nERROR e\nINFO f\n")
+	testProbe(t, p, 3)
+
+	// removal
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+	appendFile(t, name, "ERROR g\n")
+	testProbe(t, p, 1)
+}
+
+func TestRotateGlob(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	appendFile(t, name, "ERROR old\n")
+
+	p, err := construct(map[string]interface{}{
+		"path":    name + "*",
+		"pattern": "ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+
+	appendFile(t, name, "ERROR a\n")
+	testProbe(t, p, 1)
+
+	// lines appended before rotation are counted once.
+	appendFile(t, name, "ERROR b\n")
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name, "ERROR c\nERROR d\n")
+	testProbe(t, p, 3)
+	testProbe(t, p, 0)
+
+	// rotate again, as logrotate with dateext does.
+	if err := os.Rename(name, name+"-20161017"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name+".1", "ERROR e\n")
+	appendFile(t, name, "ERROR f\n")
+	testProbe(t, p, 2)
+	testProbe(t, p, 0)
+}
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p, err := construct(map[string]interface{}{
+		"path":    filepath.Join(dir, "*.log"),
+		"pattern": "ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+
+	// b.log is long enough to check ctx while scanning.
+	appendFile(t, filepath.Join(dir, "a.log"), "ERROR a\n")
+	appendFile(t, filepath.Join(dir, "b.log"), strings.Repeat("ERROR b\n", checkInterval*2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if f := p.Probe(ctx); !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+
+	// a.log is not read again.
+	testProbe(t, p, checkInterval*2)
+	testProbe(t, p, 0)
+}
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "a.log"), "ERROR old\n")
+
+	p, err := construct(map[string]interface{}{
+		"path":    filepath.Join(dir, "*.log"),
+		"pattern": "ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+
+	appendFile(t, filepath.Join(dir, "a.log"), "ERROR a\n")
+	appendFile(t, filepath.Join(dir, "b.log"), "ERROR b1\nERROR b2\n")
+	appendFile(t, filepath.Join(dir, "c.txt"), "ERROR c\n")
+	testProbe(t, p, 3)
+
+	appendFile(t, filepath.Join(dir, "b.log"), "ERROR b3\n")
+	testProbe(t, p, 1)
+}
+
+func TestLongLine(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, name, "")
+
+	p, err := construct(map[string]interface{}{
+		"path":    name,
+		"pattern": "^ERROR",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testProbe(t, p, 0)
+
+	long := strings.Repeat("x", 2*maxLineSize)
+	appendFile(t, name, "ERROR "+long+"\n"+long+" ERROR\nERROR short\n")
+	testProbe(t, p, 2)
+}
@@ -0,0 +1,31 @@
+/*
+Package memcached implements "memcached" probe type that tests
+memcached servers with the text protocol.
+
+If field is not given, the probe sends "version" command and the value
+of the probe will be the time in seconds taken to connect, authenticate,
+and receive the reply.
+
+If field is given, the probe sends "stats" command and the value of
+the probe will be the numeric value of the statistic, e.g.
+"curr_connections" or "evictions".  group specifies the argument of
+"stats" command such as "slabs" to query other statistics.
+
+If user is given, the probe authenticates with the ASCII authentication
+of memcached 1.5.15+, which requires memcached to run with -Y option.
+
+errval is returned if the probe fails to communicate with the server,
+the server replies an error, or the field is not found or not numeric.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	address   string             host:port of the server.  Required.
+	                             The port defaults to 11211.
+	user      string             User name to authenticate.  Optional.
+	password  string             Password to authenticate.
+	field     string             Statistic name.  Optional.
+	group     string             Argument of "stats" command.  Optional.
+	errval    float64  -1        Return value upon an error.
+*/
+package memcached
@@ -0,0 +1,226 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultPort   = "11211"
+	defaultErrval = -1.0
+
+	// maxStats limits the number of lines of "stats" reply.
+	maxStats = 10000
+)
+
+var (
+	errTooManyStats = errors.New("too many stats")
+)
+
+type probe struct {
+	address  string
+	user     string
+	password string
+	field    string
+	group    string
+	errval   float64
+}
+
+// readLine reads a line and returns it as an error if the server
+// replies an error.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "ERROR" ||
+		strings.HasPrefix(line, "CLIENT_ERROR") ||
+		strings.HasPrefix(line, "SERVER_ERROR") {
+		return "", errors.New(line)
+	}
+	return line, nil
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+
+	if len(p.user) > 0 {
+		// ASCII authentication sends credentials as the data of "set".
+		data := p.user + " " + p.password
+		_, err := fmt.Fprintf(conn, "set auth 0 0 %d\r\n%s\r\n", len(data), data)
+		if err != nil {
+			return p.fail(err)
+		}
+		line, err := readLine(r)
+		if err != nil {
+			return p.fail(err)
+		}
+		if line != "STORED" {
+			return p.fail(fmt.Errorf("unexpected reply: %s", line))
+		}
+	}
+
+	if len(p.field) == 0 {
+		if _, err := conn.Write([]byte("version\r\n")); err != nil {
+			return p.fail(err)
+		}
+		line, err := readLine(r)
+		if err != nil {
+			return p.fail(err)
+		}
+		if !strings.HasPrefix(line, "VERSION ") {
+			return p.fail(fmt.Errorf("unexpected reply: %s", line))
+		}
+		return time.Since(start).Seconds()
+	}
+
+	cmd := "stats\r\n"
+	if len(p.group) > 0 {
+		cmd = "stats " + p.group + "\r\n"
+	}
+	if _, err := conn.Write([]byte(cmd)); err != nil {
+		return p.fail(err)
+	}
+	v, err := p.readStats(r)
+	if err != nil {
+		return p.fail(err)
+	}
+	return v
+}
+
+// readStats reads "STAT name value" lines until "END" and returns
+// the value of p.field.
+func (p *probe) readStats(r *bufio.Reader) (float64, error) {
+	var value string
+	found := false
+	for i := 0; i < maxStats; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return 0, err
+		}
+		if line == "END" {
+			if !found {
+				return 0, fmt.Errorf("field not found: %s", p.field)
+			}
+			return strconv.ParseFloat(value, 64)
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			return 0, fmt.Errorf("unexpected reply: %s", line)
+		}
+		if fields[1] == p.field {
+			value = fields[2]
+			found = true
+		}
+	}
+	return 0, errTooManyStats
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:memcached error", map[string]interface{}{
+		"address": p.address,
+		"field":   p.field,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	if len(p.field) > 0 {
+		return fmt.Sprintf("probe:memcached:%s:%s", p.address, p.field)
+	}
+	return "probe:memcached:" + p.address
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+
+	user, err := goma.GetString("user", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if strings.ContainsAny(user, " \r\n") {
+		return nil, fmt.Errorf("invalid user: %q", user)
+	}
+	password, err := goma.GetString("password", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if len(user) == 0 && len(password) > 0 {
+		return nil, errors.New("password requires user")
+	}
+
+	field, err := goma.GetString("field", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	group, err := goma.GetString("group", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if strings.ContainsAny(group, "\r\n") {
+		return nil, fmt.Errorf("invalid group: %q", group)
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:  address,
+		user:     user,
+		password: password,
+		field:    field,
+		group:    group,
+		errval:   errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("memcached", construct)
+}
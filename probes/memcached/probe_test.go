@@ -0,0 +1,223 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+// serve emulates a memcached server.  If auth is true, commands
+// require authentication by user "goma" and password "secret".
+func serve(l net.Listener, auth bool) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			r := bufio.NewReader(c)
+			authenticated := !auth
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				args := strings.Fields(line)
+				if len(args) == 0 {
+					io.WriteString(c, "ERROR\r\n")
+					continue
+				}
+				switch {
+				case args[0] == "set":
+					data, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if !auth || authenticated {
+						io.WriteString(c, "STORED\r\n")
+						continue
+					}
+					if strings.TrimSpace(data) != "goma secret" {
+						io.WriteString(c, "CLIENT_ERROR authentication failure\r\n")
+						return
+					}
+					authenticated = true
+					io.WriteString(c, "STORED\r\n")
+				case !authenticated:
+					io.WriteString(c, "CLIENT_ERROR unauthenticated\r\n")
+					return
+				case args[0] == "version":
+					io.WriteString(c, "VERSION 1.6.9\r\n")
+				case args[0] == "stats" && len(args) == 1:
+					io.WriteString(c, "STAT pid 1\r\n"+
+						"STAT version 1.6.9\r\n"+
+						"STAT curr_connections 10\r\n"+
+						"STAT evictions 5\r\n"+
+						"END\r\n")
+				case args[0] == "stats" && args[1] == "slabs":
+					io.WriteString(c, "STAT 1:chunk_size 96\r\n"+
+						"STAT active_slabs 1\r\n"+
+						"END\r\n")
+				case args[0] == "stats" && args[1] == "sleep":
+					time.Sleep(10 * time.Second)
+					return
+				default:
+					io.WriteString(c, "ERROR\r\n")
+				}
+			}
+		}(conn)
+	}
+}
+
+func testServer(t *testing.T, auth bool) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go serve(l, auth)
+	return l.Addr().String()
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address":  "localhost",
+		"password": "secret",
+	}); err == nil {
+		t.Error("password without user should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": "localhost",
+		"user":    "go ma",
+	}); err == nil {
+		t.Error("user with spaces should be rejected")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"address": "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).address != "localhost:11211" {
+		t.Error(`p.(*probe).address != "localhost:11211"`)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, false)
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+	f = testProbe(t, map[string]interface{}{
+		"address": closed,
+		"errval":  100.0,
+	})
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, true)
+	cases := []struct {
+		params   map[string]interface{}
+		expected float64
+	}{
+		{map[string]interface{}{"address": addr, "field": "evictions"}, -1},
+		{map[string]interface{}{"address": addr, "field": "evictions",
+			"user": "goma", "password": "secret"}, 5},
+		{map[string]interface{}{"address": addr, "field": "evictions",
+			"user": "goma", "password": "wrong"}, -1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, c.params)
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.params, f, "!=", c.expected)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, false)
+	cases := []struct {
+		field    string
+		group    string
+		expected float64
+	}{
+		{"curr_connections", "", 10},
+		{"evictions", "", 5},
+		{"version", "", -1},
+		{"nosuchfield", "", -1},
+		{"active_slabs", "slabs", 1},
+		{"evictions", "nosuchgroup", -1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, map[string]interface{}{
+			"address": addr,
+			"field":   c.field,
+			"group":   c.group,
+		})
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.field, c.group, f, "!=", c.expected)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, false)
+	start := time.Now()
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+		"field":   "evictions",
+		"group":   "sleep",
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
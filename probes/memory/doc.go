@@ -0,0 +1,22 @@
+/*
+Package memory implements "memory" probe type that reads memory usage
+from /proc/meminfo.
+
+The value of the probe is determined by mode:
+
+	Mode        Value
+	available   MemAvailable / MemTotal
+	used        1 - MemAvailable / MemTotal
+	swap_used   1 - SwapFree / SwapTotal, or 0 if there is no swap.
+
+On kernels without MemAvailable, MemFree + Buffers + Cached is used
+instead.  If the file cannot be read, errval is returned.
+
+The constructor takes these parameters:
+
+	Name      Type     Default     Description
+	mode      string   available   One of the above modes.
+	proc      string   /proc       Mount point of procfs.
+	errval    float64  -1          Return value upon an error.
+*/
+package memory
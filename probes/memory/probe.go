@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeAvailable = "available"
+	modeUsed      = "used"
+	modeSwapUsed  = "swap_used"
+
+	defaultProc   = "/proc"
+	defaultErrval = -1.0
+)
+
+var (
+	errNoMemTotal = errors.New("no MemTotal in meminfo")
+)
+
+type probe struct {
+	mode   string
+	proc   string
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	info, err := p.meminfo()
+	if err != nil {
+		return p.fail(err)
+	}
+
+	if p.mode == modeSwapUsed {
+		if info["SwapTotal"] == 0 {
+			return 0
+		}
+		return 1 - info["SwapFree"]/info["SwapTotal"]
+	}
+
+	total := info["MemTotal"]
+	if total == 0 {
+		return p.fail(errNoMemTotal)
+	}
+	available, ok := info["MemAvailable"]
+	if !ok {
+		available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	ratio := available / total
+	if p.mode == modeUsed {
+		return 1 - ratio
+	}
+	return ratio
+}
+
+// meminfo parses meminfo into a map of kB values.
+func (p *probe) meminfo() (map[string]float64, error) {
+	data, err := os.ReadFile(filepath.Join(p.proc, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := make(map[string]float64)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		info[key] = v
+	}
+	return info, nil
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:memory error", map[string]interface{}{
+		"proc":  p.proc,
+		"error": err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return "probe:memory:" + p.mode
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeAvailable, modeUsed, modeSwapUsed:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeAvailable
+	default:
+		return nil, err
+	}
+
+	proc, err := goma.GetString("proc", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		proc = defaultProc
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		mode:   mode,
+		proc:   proc,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("memory", construct)
+}
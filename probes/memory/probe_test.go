@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+)
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Probe(context.Background())
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(map[string]interface{}{
+		"mode": "free",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		proc     string
+		mode     string
+		expected float64
+	}{
+		{"testdata/new", "available", 0.75},
+		{"testdata/new", "used", 0.25},
+		{"testdata/new", "swap_used", 0.25},
+		{"testdata/old", "available", 0.5},
+		{"testdata/old", "used", 0.5},
+		{"testdata/old", "swap_used", 0},
+		{"nosuchdir", "available", -1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, map[string]interface{}{
+			"mode": c.mode,
+			"proc": c.proc,
+		})
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.proc, c.mode, f, "!=", c.expected)
+		}
+	}
+}
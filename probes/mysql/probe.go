@@ -84,6 +84,11 @@ func (p *probe) String() string {
 	return fmt.Sprintf("mysql:%s:%s", p.dsn, p.query)
 }
 
+// Close closes the connection pool.
+func (p *probe) Close() error {
+	return p.db.Close()
+}
+
 func construct(params map[string]interface{}) (probes.Prober, error) {
 	dsn, err := goma.GetString("dsn", params)
 	if err != nil {
@@ -0,0 +1,32 @@
+/*
+Package ping implements "ping" probe type that sends ICMP echo requests.
+
+The probe sends count echo requests to the address, then returns
+a value determined by mode:
+
+	Mode   Value
+	loss   The ratio of lost packets between 0.0 and 1.0.
+	avg    The average round trip time in seconds.
+	max    The maximum round trip time in seconds.
+
+For "avg" and "max", errval is returned if no reply is received.
+errval is also returned if the address cannot be resolved or packets
+cannot be sent.
+
+The probe uses unprivileged ICMP sockets if the system allows them
+(see net.ipv4.ping_group_range sysctl on Linux), or falls back to raw
+sockets that require privileges such as CAP_NET_RAW.
+
+The constructor takes these parameters:
+
+	Name       Type     Default   Description
+	address    string             Host name or IP address.  Required.
+	mode       string   loss      One of "loss", "avg", or "max".
+	count      int      3         The number of echo requests.
+	interval   float64  0.2       Seconds between echo requests.
+	wait       float64  1.0       Seconds to wait for replies after the
+	                              last echo request.
+	size       int      56        Payload size in bytes.
+	errval     float64  -1        Return value upon an error.
+*/
+package ping
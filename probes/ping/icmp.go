@@ -0,0 +1,68 @@
+package ping
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ICMP message types for echo requests and replies.
+const (
+	typeEchoRequest   = 8
+	typeEchoReply     = 0
+	typeEchoRequestV6 = 128
+	typeEchoReplyV6   = 129
+
+	echoHeaderSize = 8
+)
+
+var errShortMessage = errors.New("short ICMP message")
+
+// echo is an ICMP echo request or reply message.
+type echo struct {
+	Type int
+	ID   int
+	Seq  int
+	Data []byte
+}
+
+// marshal encodes m into the wire format.
+//
+// The checksum is always calculated though kernels calculate it
+// for ICMPv6 and unprivileged ICMP sockets.
+func (m *echo) marshal() []byte {
+	b := make([]byte, echoHeaderSize+len(m.Data))
+	b[0] = byte(m.Type)
+	binary.BigEndian.PutUint16(b[4:], uint16(m.ID))
+	binary.BigEndian.PutUint16(b[6:], uint16(m.Seq))
+	copy(b[echoHeaderSize:], m.Data)
+	binary.BigEndian.PutUint16(b[2:], checksum(b))
+	return b
+}
+
+// parseEcho decodes an ICMP echo message.  Data refers b.
+func parseEcho(b []byte) (*echo, error) {
+	if len(b) < echoHeaderSize {
+		return nil, errShortMessage
+	}
+	return &echo{
+		Type: int(b[0]),
+		ID:   int(binary.BigEndian.Uint16(b[4:])),
+		Seq:  int(binary.BigEndian.Uint16(b[6:])),
+		Data: b[echoHeaderSize:],
+	}, nil
+}
+
+// checksum calculates the internet checksum defined in RFC 1071.
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for ; len(b) >= 2; b = b[2:] {
+		sum += uint32(b[0])<<8 | uint32(b[1])
+	}
+	if len(b) == 1 {
+		sum += uint32(b[0]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,321 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeLoss = "loss"
+	modeAvg  = "avg"
+	modeMax  = "max"
+
+	defaultCount    = 3
+	defaultInterval = 0.2
+	defaultWait     = 1.0
+	defaultSize     = 56
+	defaultErrval   = -1.0
+
+	// the payload begins with the time when the request is sent.
+	minSize = 8
+)
+
+var (
+	errNoAddress = errors.New("no address found")
+
+	// ICMP echo identifier is shared among probes using raw sockets.
+	echoID = uint32(rand.Intn(0x10000))
+)
+
+type probe struct {
+	address  string
+	mode     string
+	count    int
+	interval time.Duration
+	wait     time.Duration
+	size     int
+	errval   float64
+	seq      int
+}
+
+// endpoint is an ICMP socket.
+type endpoint struct {
+	conn net.PacketConn
+	dst  net.Addr
+	reqT int
+	repT int
+
+	// id is valid only for raw sockets.  The kernel rewrites IDs
+	// of unprivileged sockets.
+	raw bool
+	id  int
+}
+
+// listen opens an unprivileged ICMP socket if possible, or a raw socket.
+func listen(ip net.IP) (*endpoint, error) {
+	v6 := ip.To4() == nil
+	e := &endpoint{reqT: typeEchoRequest, repT: typeEchoReply}
+	network := "ip4:icmp"
+	if v6 {
+		e.reqT, e.repT = typeEchoRequestV6, typeEchoReplyV6
+		network = "ip6:ipv6-icmp"
+	}
+
+	conn, err := listenDatagram(v6)
+	if err == nil {
+		e.conn = conn
+		e.dst = &net.UDPAddr{IP: ip}
+		return e, nil
+	}
+
+	conn, err = net.ListenPacket(network, "")
+	if err != nil {
+		return nil, err
+	}
+	e.conn = conn
+	e.dst = &net.IPAddr{IP: ip}
+	e.raw = true
+	return e, nil
+}
+
+func (e *endpoint) send(seq, size int) error {
+	data := make([]byte, size)
+	binary.BigEndian.PutUint64(data, uint64(time.Now().UnixNano()))
+	msg := &echo{
+		Type: e.reqT,
+		ID:   e.id,
+		Seq:  seq,
+		Data: data,
+	}
+	_, err := e.conn.WriteTo(msg.marshal(), e.dst)
+	return err
+}
+
+// receive reads echo replies for sequence numbers from seq to seq+count-1,
+// and sends their round trip times to ch until the connection is closed.
+func (e *endpoint) receive(seq, count int, ch chan<- time.Duration) {
+	received := make(map[int]bool)
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := e.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+
+		msg, err := parseEcho(buf[:n])
+		if err != nil || msg.Type != e.repT || len(msg.Data) < minSize {
+			continue
+		}
+		if e.raw {
+			if msg.ID != e.id || peer.String() != e.dst.String() {
+				continue
+			}
+		}
+		// sequence numbers are 16 bit.
+		i := (msg.Seq - seq) & 0xffff
+		if i >= count || received[i] {
+			continue
+		}
+		received[i] = true
+
+		sent := time.Unix(0, int64(binary.BigEndian.Uint64(msg.Data)))
+		ch <- now.Sub(sent)
+	}
+}
+
+func (p *probe) resolve(ctx context.Context) (net.IP, error) {
+	if ip := net.ParseIP(p.address); ip != nil {
+		return ip, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, p.address)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errNoAddress
+	}
+	return addrs[0].IP, nil
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	ip, err := p.resolve(ctx)
+	if err != nil {
+		return p.fail(err)
+	}
+
+	e, err := listen(ip)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer e.conn.Close()
+	e.id = int(atomic.AddUint32(&echoID, 1) & 0xffff)
+
+	seq := p.seq
+	p.seq = (p.seq + p.count) & 0xffff
+
+	replies := make(chan time.Duration, p.count)
+	go e.receive(seq, p.count, replies)
+
+	var rtts []time.Duration
+	collect := func(d time.Duration) bool {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		for len(rtts) < p.count {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-timer.C:
+				return true
+			case rtt := <-replies:
+				rtts = append(rtts, rtt)
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < p.count; i++ {
+		if i > 0 && !collect(p.interval) {
+			return p.errval
+		}
+		if err := e.send((seq+i)&0xffff, p.size); err != nil {
+			return p.fail(err)
+		}
+	}
+	if !collect(p.wait) {
+		return p.errval
+	}
+
+	switch p.mode {
+	case modeAvg:
+		if len(rtts) == 0 {
+			return p.errval
+		}
+		var total time.Duration
+		for _, rtt := range rtts {
+			total += rtt
+		}
+		return total.Seconds() / float64(len(rtts))
+	case modeMax:
+		if len(rtts) == 0 {
+			return p.errval
+		}
+		var max time.Duration
+		for _, rtt := range rtts {
+			if rtt > max {
+				max = rtt
+			}
+		}
+		return max.Seconds()
+	}
+	return float64(p.count-len(rtts)) / float64(p.count)
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:ping error", map[string]interface{}{
+		"address": p.address,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:ping:%s:%s", p.address, p.mode)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeLoss, modeAvg, modeMax:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeLoss
+	default:
+		return nil, err
+	}
+
+	count, err := goma.GetInt("count", params)
+	switch err {
+	case nil:
+		if count < 1 {
+			return nil, fmt.Errorf("invalid count: %d", count)
+		}
+	case goma.ErrNoKey:
+		count = defaultCount
+	default:
+		return nil, err
+	}
+
+	interval, err := goma.GetFloat("interval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		interval = defaultInterval
+	default:
+		return nil, err
+	}
+
+	wait, err := goma.GetFloat("wait", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		wait = defaultWait
+	default:
+		return nil, err
+	}
+
+	size, err := goma.GetInt("size", params)
+	switch err {
+	case nil:
+		if size < minSize {
+			return nil, fmt.Errorf("too small size: %d", size)
+		}
+	case goma.ErrNoKey:
+		size = defaultSize
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:  address,
+		mode:     mode,
+		count:    count,
+		interval: time.Duration(interval * float64(time.Second)),
+		wait:     time.Duration(wait * float64(time.Second)),
+		size:     size,
+		errval:   errval,
+		seq:      rand.Intn(0x10000),
+	}, nil
+}
+
+func init() {
+	probes.Register("ping", construct)
+}
@@ -0,0 +1,122 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+const (
+	testAddress = "127.0.0.1"
+)
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 3*time.Second)
+}
+
+// skipIfNoICMP skips tests if neither unprivileged nor raw ICMP
+// sockets are available.
+func skipIfNoICMP(t *testing.T) {
+	e, err := listen(net.ParseIP(testAddress))
+	if err != nil {
+		t.Skip("ICMP sockets are not available:", err)
+	}
+	e.conn.Close()
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"mode":    "min",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"count":   0,
+	}); err == nil {
+		t.Error("zero count should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"size":    4,
+	}); err == nil {
+		t.Error("too small size should be rejected")
+	}
+}
+
+func TestLoss(t *testing.T) {
+	t.Parallel()
+	skipIfNoICMP(t)
+
+	p, err := construct(map[string]interface{}{
+		"address":  testAddress,
+		"interval": 0.05,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, 0) {
+		t.Error(`!goma.FloatEquals(f, 0)`, f)
+	}
+}
+
+func TestRTT(t *testing.T) {
+	t.Parallel()
+	skipIfNoICMP(t)
+
+	for _, mode := range []string{modeAvg, modeMax} {
+		p, err := construct(map[string]interface{}{
+			"address":  testAddress,
+			"mode":     mode,
+			"count":    2,
+			"interval": 0.05,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := testContext()
+		f := p.Probe(ctx)
+		cancel()
+		if f < 0 || f > 1 {
+			t.Error(mode, `unexpected round trip time:`, f)
+		}
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	t.Parallel()
+	skipIfNoICMP(t)
+
+	p, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"wait":    10.0,
+		"count":   100,
+		"errval":  100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package ping
+
+import (
+	"errors"
+	"net"
+)
+
+func listenDatagram(v6 bool) (net.PacketConn, error) {
+	return nil, errors.New("unprivileged ICMP is not supported")
+}
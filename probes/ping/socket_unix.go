@@ -0,0 +1,29 @@
+//go:build linux || darwin
+
+package ping
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenDatagram opens an unprivileged ICMP socket.
+//
+// Replies to the socket can be read without IP headers, and
+// the kernel rewrites echo identifiers to distinguish sockets.
+func listenDatagram(v6 bool) (net.PacketConn, error) {
+	family, proto := syscall.AF_INET, syscall.IPPROTO_ICMP
+	if v6 {
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+	}
+
+	fd, err := syscall.Socket(family, syscall.SOCK_DGRAM, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	f := os.NewFile(uintptr(fd), "icmp")
+	defer f.Close()
+
+	return net.FilePacketConn(f)
+}
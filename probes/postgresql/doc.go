@@ -0,0 +1,20 @@
+/*
+Package postgresql implements "postgresql" probe type that test PostgreSQL servers.
+
+The underlying driver is https://github.com/lib/pq .
+
+The value returned from a SELECT query will be the value of the probe.
+The SELECT statement should return a floating point value.
+
+The constructor takes these parameters:
+
+	Name       Type     Default   Description
+	dsn        string             DSN for PostgreSQL server.  Required.
+	query      string             SELECT statement.  Required.
+	errval     float64  0         Return value upon an error.
+
+This probe sets statement_timeout of the session to the deadline
+of the probe.  In addition, the probe cancels the running query by
+pg_cancel_backend() when the deadline expires.
+*/
+package postgresql
@@ -0,0 +1,152 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+	// for driver
+	_ "github.com/lib/pq"
+)
+
+// driverName can be replaced for tests.
+var driverName = "postgres"
+
+// cancelTimeout is the timeout to cancel a query that exceeds the deadline.
+const cancelTimeout = 5 * time.Second
+
+// Obtain the backend process ID by "SELECT pg_backend_pid()",
+// cancel the query by "SELECT pg_cancel_backend(PID)".
+
+type probe struct {
+	dsn    string
+	db     *sql.DB
+	query  string
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	// statement_timeout is a session variable, so the query needs
+	// to run on the same connection.
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		log.Error("probe:postgresql db.Conn", map[string]interface{}{
+			"dsn":   p.dsn,
+			"error": err.Error(),
+		})
+		return p.errval
+	}
+
+	var timeout int64
+	deadline, ok := ctx.Deadline()
+	if ok {
+		timeout = int64(time.Until(deadline) / time.Millisecond)
+		if timeout < 1 {
+			timeout = 1
+		}
+	}
+
+	var pid int64
+	var setting string
+	err = conn.QueryRowContext(ctx,
+		"SELECT pg_backend_pid(), set_config('statement_timeout', $1, false)",
+		strconv.FormatInt(timeout, 10)).Scan(&pid, &setting)
+	if err != nil {
+		conn.Close()
+		log.Error("probe:postgresql SET statement_timeout", map[string]interface{}{
+			"dsn":   p.dsn,
+			"error": err.Error(),
+		})
+		return p.errval
+	}
+
+	done := make(chan float64, 1)
+	go func() {
+		defer conn.Close()
+
+		var v float64
+		err := conn.QueryRowContext(context.Background(), p.query).Scan(&v)
+		if err != nil {
+			done <- p.errval
+			log.Error("probe:postgresql db.QueryRow", map[string]interface{}{
+				"dsn":   p.dsn,
+				"error": err.Error(),
+			})
+			return
+		}
+		done <- v
+	}()
+
+	select {
+	case <-ctx.Done():
+		go p.cancel(pid)
+		return p.errval
+	case v := <-done:
+		return v
+	}
+}
+
+// cancel cancels the query running on the backend process.
+func (p *probe) cancel(pid int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, "SELECT pg_cancel_backend($1)", pid)
+	if err != nil {
+		log.Error("probe:postgresql pg_cancel_backend", map[string]interface{}{
+			"dsn":   p.dsn,
+			"pid":   pid,
+			"error": err.Error(),
+		})
+	}
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("postgresql:%s:%s", p.dsn, p.query)
+}
+
+// Close closes the connection pool.
+func (p *probe) Close() error {
+	return p.db.Close()
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	dsn, err := goma.GetString("dsn", params)
+	if err != nil {
+		return nil, err
+	}
+	query, err := goma.GetString("query", params)
+	if err != nil {
+		return nil, err
+	}
+	errval, err := goma.GetFloat("errval", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &probe{
+		dsn:    dsn,
+		db:     db,
+		query:  query,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("postgresql", construct)
+}
@@ -0,0 +1,303 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+// fakeDriver emulates a small subset of PostgreSQL.
+//
+//	SELECT pg_backend_pid(), set_config(...)   returns PID and the timeout
+//	SELECT pg_cancel_backend($1)               cancels pg_sleep of PID
+//	SELECT pg_sleep(10)                        sleeps until canceled
+//	SELECT <number>                            returns the number
+type fakeDriver struct {
+	mu       sync.Mutex
+	lastPID  int64
+	timeouts map[int64]string
+	cancels  map[int64]chan struct{}
+}
+
+var testDriver = &fakeDriver{
+	timeouts: make(map[int64]string),
+	cancels:  make(map[int64]chan struct{}),
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastPID++
+	d.cancels[d.lastPID] = make(chan struct{})
+	return &fakeConn{d: d, pid: d.lastPID}, nil
+}
+
+func (d *fakeDriver) timeout(pid int64) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.timeouts[pid]
+}
+
+type fakeConn struct {
+	d   *fakeDriver
+	pid int64
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, err := s.Query(args)
+	return driver.ResultNoRows, err
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.c.d
+	switch {
+	case strings.HasPrefix(s.query, "SELECT pg_backend_pid(),"):
+		d.mu.Lock()
+		d.timeouts[s.c.pid] = args[0].(string)
+		d.mu.Unlock()
+		return &fakeRows{
+			columns: []string{"pg_backend_pid", "set_config"},
+			values:  []driver.Value{s.c.pid, args[0]},
+		}, nil
+	case strings.HasPrefix(s.query, "SELECT pg_cancel_backend("):
+		d.mu.Lock()
+		close(d.cancels[args[0].(int64)])
+		d.mu.Unlock()
+		return &fakeRows{
+			columns: []string{"pg_cancel_backend"},
+			values:  []driver.Value{true},
+		}, nil
+	case s.query == "SELECT pg_sleep(10)":
+		d.mu.Lock()
+		ch := d.cancels[s.c.pid]
+		d.mu.Unlock()
+		select {
+		case <-ch:
+			return nil, errors.New("canceling statement due to user request")
+		case <-time.After(10 * time.Second):
+			return nil, errors.New("not canceled")
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimPrefix(s.query, "SELECT "), 64)
+	if err != nil {
+		return nil, errors.New("syntax error")
+	}
+	return &fakeRows{
+		columns: []string{"?column?"},
+		values:  []driver.Value{f},
+	}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  []driver.Value
+	done    bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	sql.Register("postgresfake", testDriver)
+	driverName = "postgresfake"
+	os.Exit(m.Run())
+}
+
+func testProbe(t *testing.T, params map[string]interface{}, timeout time.Duration) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	_, err := construct(nil)
+	if err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+
+	_, err = construct(map[string]interface{}{
+		"dsn": "fake",
+	})
+	if err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+
+	v := testProbe(t, map[string]interface{}{
+		"dsn":   "fake",
+		"query": "SELECT 1",
+	}, 10*time.Second)
+	if !goma.FloatEquals(v, 1.0) {
+		t.Error(`!goma.FloatEquals(v, 1.0)`)
+	}
+}
+
+func TestFloat(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"dsn":   "fake",
+		"query": "SELECT 123.45",
+	}, 10*time.Second)
+	if !goma.FloatEquals(v, 123.45) {
+		t.Error(`!goma.FloatEquals(v, 123.45)`)
+	}
+}
+
+func TestErrval(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"dsn":   "fake",
+		"query": "SELECT hogenotfound()",
+	}, 10*time.Second)
+	if v != 0 {
+		t.Error(`v != 0`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"dsn":    "fake",
+		"query":  "SELECT hogenotfound()",
+		"errval": 123,
+	}, 10*time.Second)
+	if !goma.FloatEquals(v, 123) {
+		t.Error(`!goma.FloatEquals(v, 123)`)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"dsn":    "fake",
+		"query":  "SELECT pg_sleep(10)",
+		"errval": 123.45,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	start := time.Now()
+	v := p.Probe(ctx)
+	if !goma.FloatEquals(v, 123.45) {
+		t.Error(`!goma.FloatEquals(v, 123.45)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+
+	// the connection used for the query should be returned to the pool
+	// after pg_cancel_backend.
+	db := p.(*probe).db
+	deadline := time.Now().Add(5 * time.Second)
+	for db.Stats().InUse > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the query was not canceled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStatementTimeout(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"dsn":   "fake",
+		"query": "SELECT 1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		p.Probe(ctx)
+		cancel()
+	}
+
+	db := p.(*probe).db
+	if n := db.Stats().OpenConnections; n != 1 {
+		t.Error("connection is not reused:", n)
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var pid int64
+	conn.Raw(func(c interface{}) error {
+		pid = c.(*fakeConn).pid
+		return nil
+	})
+	timeout, err := strconv.Atoi(testDriver.timeout(pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout <= 0 || timeout > 2000 {
+		t.Error("unexpected statement_timeout:", timeout)
+	}
+}
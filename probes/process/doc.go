@@ -0,0 +1,40 @@
+/*
+Package process implements "process" probe type that checks processes
+by reading /proc.
+
+Processes are selected by one or more of these parameters.
+If more than one is given, processes must match all of them.
+
+	Name      Description
+	name      Process name in /proc/PID/comm.
+	cmdline   Regexp for the command line joined with spaces.
+	pidfile   File containing the process ID.
+	cgroup    Cgroup path such as "/system.slice/nginx.service".
+	          Processes in descendant cgroups are also selected.
+
+The kernel truncates process names in /proc/PID/comm to 15 bytes, so
+name longer than that is rejected.  Use cmdline for such processes.
+
+The value of the probe is determined by mode:
+
+	Mode    Value
+	count   The number of processes.
+	rss     The total resident set size in bytes.
+	cpu     The total CPU usage in percent since the last probe.
+	        100 means one CPU is fully used.
+	fds     The total number of open file descriptors.
+
+For "cpu", the usage of processes that were not found by the last probe
+is averaged since they started.  The clock tick is assumed to be 100Hz.
+
+If no process is found, the value is 0 for "count" or errval for others.
+errval is also returned if /proc cannot be read.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	mode      string   count     One of the above modes.
+	proc      string   /proc     Mount point of procfs.
+	errval    float64  -1        Return value upon an error.
+*/
+package process
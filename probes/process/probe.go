@@ -0,0 +1,301 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeCount = "count"
+	modeRSS   = "rss"
+	modeCPU   = "cpu"
+	modeFDs   = "fds"
+
+	defaultProc   = "/proc"
+	defaultErrval = -1.0
+
+	// maxCommLen is the maximum length of /proc/PID/comm
+	// (TASK_COMM_LEN - 1).
+	maxCommLen = 15
+)
+
+var (
+	errNoSelector = errors.New("one of name, cmdline, pidfile, or cgroup is required")
+	errNoProcess  = errors.New("no process found")
+)
+
+type probe struct {
+	name    string
+	cmdline *regexp.Regexp
+	pidfile string
+	cgroup  string
+	mode    string
+	proc    procfs
+	errval  float64
+
+	// CPU ticks of processes at the last probe.
+	lock       sync.Mutex
+	lastUptime float64
+	lastTicks  map[int]uint64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	pids, err := p.find()
+	if err != nil {
+		return p.fail(err)
+	}
+
+	if p.mode == modeCount {
+		return float64(len(pids))
+	}
+	if len(pids) == 0 {
+		return p.fail(errNoProcess)
+	}
+
+	var v float64
+	switch p.mode {
+	case modeRSS:
+		for _, pid := range pids {
+			rss, err := p.proc.rss(pid)
+			if err != nil {
+				continue
+			}
+			v += float64(rss)
+		}
+	case modeFDs:
+		for _, pid := range pids {
+			n, err := p.proc.fds(pid)
+			if err != nil {
+				continue
+			}
+			v += float64(n)
+		}
+	case modeCPU:
+		v, err = p.cpu(pids)
+		if err != nil {
+			return p.fail(err)
+		}
+	}
+	return v
+}
+
+// cpu returns CPU usage in percent since the last probe.
+func (p *probe) cpu(pids []int) (float64, error) {
+	uptime, err := p.proc.uptime()
+	if err != nil {
+		return 0, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var total float64
+	ticks := make(map[int]uint64)
+	for _, pid := range pids {
+		t, start, err := p.proc.cpuTicks(pid)
+		if err != nil {
+			continue
+		}
+		ticks[pid] = t
+
+		last, ok := p.lastTicks[pid]
+		if ok && t >= last && uptime > p.lastUptime {
+			total += float64(t-last) / clockTick / (uptime - p.lastUptime)
+			continue
+		}
+		elapsed := uptime - float64(start)/clockTick
+		if elapsed > 0 {
+			total += float64(t) / clockTick / elapsed
+		}
+	}
+	p.lastUptime = uptime
+	p.lastTicks = ticks
+	return total * 100, nil
+}
+
+// find returns IDs of processes that match the selectors.
+func (p *probe) find() ([]int, error) {
+	var candidates []int
+	if len(p.pidfile) > 0 {
+		data, err := os.ReadFile(p.pidfile)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p.pidfile, err)
+		}
+		candidates = []int{pid}
+	} else {
+		pids, err := p.proc.pids()
+		if err != nil {
+			return nil, err
+		}
+		candidates = pids
+	}
+
+	var pids []int
+	for _, pid := range candidates {
+		// processes may exit at any time.
+		if _, err := os.Stat(p.proc.path(strconv.Itoa(pid))); err != nil {
+			continue
+		}
+		if p.match(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+func (p *probe) match(pid int) bool {
+	if len(p.name) > 0 {
+		comm, err := p.proc.comm(pid)
+		if err != nil || comm != p.name {
+			return false
+		}
+	}
+
+	if p.cmdline != nil {
+		cmdline, err := p.proc.cmdline(pid)
+		if err != nil || !p.cmdline.MatchString(cmdline) {
+			return false
+		}
+	}
+
+	if len(p.cgroup) > 0 {
+		paths, err := p.proc.cgroups(pid)
+		if err != nil {
+			return false
+		}
+		for _, path := range paths {
+			if path == p.cgroup || strings.HasPrefix(path, p.cgroup+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:process error", map[string]interface{}{
+		"process": p.selector(),
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) selector() string {
+	var l []string
+	if len(p.name) > 0 {
+		l = append(l, "name="+p.name)
+	}
+	if p.cmdline != nil {
+		l = append(l, "cmdline="+p.cmdline.String())
+	}
+	if len(p.pidfile) > 0 {
+		l = append(l, "pidfile="+p.pidfile)
+	}
+	if len(p.cgroup) > 0 {
+		l = append(l, "cgroup="+p.cgroup)
+	}
+	return strings.Join(l, ",")
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:process:%s:%s", p.selector(), p.mode)
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	name, err := goma.GetString("name", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if len(name) > maxCommLen {
+		return nil, fmt.Errorf("name is longer than %d bytes: %s", maxCommLen, name)
+	}
+
+	var cmdline *regexp.Regexp
+	switch s, err := goma.GetString("cmdline", params); err {
+	case nil:
+		cmdline, err = regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	pidfile, err := goma.GetString("pidfile", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	cgroup, err := goma.GetString("cgroup", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	cgroup = strings.TrimSuffix(cgroup, "/")
+	if len(name) == 0 && cmdline == nil && len(pidfile) == 0 && len(cgroup) == 0 {
+		return nil, errNoSelector
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeCount, modeRSS, modeCPU, modeFDs:
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeCount
+	default:
+		return nil, err
+	}
+
+	proc, err := goma.GetString("proc", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		proc = defaultProc
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		name:    name,
+		cmdline: cmdline,
+		pidfile: pidfile,
+		cgroup:  cgroup,
+		mode:    mode,
+		proc:    procfs(proc),
+		errval:  errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("process", construct)
+}
@@ -0,0 +1,232 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/cybozu-go/goma"
+)
+
+type testProcess struct {
+	pid     int
+	comm    string
+	cmdline []string
+	cgroup  string
+	ticks   int
+	start   int
+	rssKB   int
+	fds     int
+}
+
+var testProcesses = []testProcess{
+	{100, "nginx", []string{"nginx: master process /usr/sbin/nginx"}, "/system.slice/nginx.service", 150, 1000, 1000, 3},
+	{101, "nginx", []string{"nginx: worker process"}, "/system.slice/nginx.service/worker", 300, 1000, 2000, 5},
+	{200, "my (proc)", []string{"/usr/bin/my", "-D"}, "/system.slice/my.service", 0, 5000, 500, 1},
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeStat(t *testing.T, proc string, pid int, comm string, ticks, start int) {
+	t.Helper()
+
+	// utime and stime are the 14th and 15th, starttime is the 22nd field.
+	stat := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 4194560 100 0 0 0 %d %d 0 0 20 0 1 0 %d 1000000 250 0 0\n",
+		pid, comm, ticks/2, ticks-ticks/2, start)
+	writeFile(t, filepath.Join(proc, strconv.Itoa(pid), "stat"), stat)
+}
+
+// makeProc creates a fake procfs.
+func makeProc(t *testing.T) string {
+	t.Helper()
+
+	proc := t.TempDir()
+	writeFile(t, filepath.Join(proc, "uptime"), "110.00 200.00\n")
+	if err := os.Mkdir(filepath.Join(proc, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tp := range testProcesses {
+		dir := filepath.Join(proc, strconv.Itoa(tp.pid))
+		if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "comm"), tp.comm+"\n")
+		var cmdline string
+		for _, arg := range tp.cmdline {
+			cmdline += arg + "\x00"
+		}
+		writeFile(t, filepath.Join(dir, "cmdline"), cmdline)
+		writeFile(t, filepath.Join(dir, "cgroup"), "0::"+tp.cgroup+"\n")
+		writeFile(t, filepath.Join(dir, "status"), fmt.Sprintf("Name:\t%s\nVmRSS:\t%d kB\nThreads:\t1\n", tp.comm, tp.rssKB))
+		writeStat(t, proc, tp.pid, tp.comm, tp.ticks, tp.start)
+		for i := 0; i < tp.fds; i++ {
+			writeFile(t, filepath.Join(dir, "fd", strconv.Itoa(i)), "")
+		}
+	}
+	return proc
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != errNoSelector {
+		t.Error(`err != errNoSelector`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"name": "nginx",
+		"mode": "vsz",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"cmdline": "(",
+	}); err == nil {
+		t.Error("invalid regexp should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"name": "systemd-journald",
+	}); err == nil {
+		t.Error("too long name should be rejected")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	proc := makeProc(t)
+	pidfile := filepath.Join(t.TempDir(), "my.pid")
+	writeFile(t, pidfile, "200\n")
+
+	cases := []struct {
+		params   map[string]interface{}
+		expected float64
+	}{
+		{map[string]interface{}{"name": "nginx"}, 2},
+		{map[string]interface{}{"name": "my (proc)"}, 1},
+		{map[string]interface{}{"name": "apache2"}, 0},
+		{map[string]interface{}{"cmdline": "^nginx: worker"}, 1},
+		{map[string]interface{}{"cmdline": "my -D$"}, 1},
+		{map[string]interface{}{"name": "nginx", "cmdline": "master"}, 1},
+		{map[string]interface{}{"cgroup": "/system.slice/nginx.service"}, 2},
+		{map[string]interface{}{"cgroup": "/system.slice/nginx.service/worker"}, 1},
+		{map[string]interface{}{"cgroup": "/system.slice/nginx"}, 0},
+		{map[string]interface{}{"pidfile": pidfile}, 1},
+		{map[string]interface{}{"pidfile": pidfile, "name": "nginx"}, 0},
+		{map[string]interface{}{"pidfile": pidfile + ".none"}, 0},
+	}
+	for _, c := range cases {
+		c.params["proc"] = proc
+		p, err := construct(c.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.params, f, "!=", c.expected)
+		}
+	}
+}
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	proc := makeProc(t)
+	cases := []struct {
+		mode     string
+		expected float64
+	}{
+		{"rss", 3000 * 1024},
+		{"fds", 8},
+	}
+	for _, c := range cases {
+		p, err := construct(map[string]interface{}{
+			"name": "nginx",
+			"mode": c.mode,
+			"proc": proc,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.mode, f, "!=", c.expected)
+		}
+	}
+
+	p, err := construct(map[string]interface{}{
+		"name":   "apache2",
+		"mode":   "rss",
+		"proc":   proc,
+		"errval": 100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := p.Probe(context.Background())
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`no process: !goma.FloatEquals(f, 100.0)`)
+	}
+}
+
+func TestCPU(t *testing.T) {
+	t.Parallel()
+
+	proc := makeProc(t)
+	p, err := construct(map[string]interface{}{
+		"name": "nginx",
+		"mode": "cpu",
+		"proc": proc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// averaged since the start (10s after boot):
+	// 1.5s / 100s + 3s / 100s
+	f := p.Probe(context.Background())
+	if !goma.FloatEquals(f, 4.5) {
+		t.Error(`!goma.FloatEquals(f, 4.5)`, f)
+	}
+
+	// 1s / 10s since the last probe
+	writeFile(t, filepath.Join(proc, "uptime"), "120.00 210.00\n")
+	writeStat(t, proc, 100, "nginx", 250, 1000)
+	f = p.Probe(context.Background())
+	if !goma.FloatEquals(f, 10) {
+		t.Error(`!goma.FloatEquals(f, 10)`, f)
+	}
+}
+
+func TestSelf(t *testing.T) {
+	t.Parallel()
+
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no procfs")
+	}
+
+	pidfile := filepath.Join(t.TempDir(), "self.pid")
+	writeFile(t, pidfile, strconv.Itoa(os.Getpid()))
+
+	for _, mode := range []string{"count", "rss", "cpu", "fds"} {
+		p, err := construct(map[string]interface{}{
+			"pidfile": pidfile,
+			"mode":    mode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := p.Probe(context.Background())
+		if f < 0 || (mode != "cpu" && f == 0) {
+			t.Error(mode, `unexpected value:`, f)
+		}
+	}
+}
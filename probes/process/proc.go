@@ -0,0 +1,166 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// clockTick is USER_HZ, which is 100 on most Linux systems.
+	clockTick = 100
+)
+
+var (
+	errInvalidStat   = errors.New("invalid stat")
+	errInvalidUptime = errors.New("invalid uptime")
+	errNoVmRSS       = errors.New("no VmRSS in status")
+)
+
+// procfs reads files under the mount point of procfs.
+type procfs string
+
+func (fs procfs) path(elem ...string) string {
+	return filepath.Join(append([]string{string(fs)}, elem...)...)
+}
+
+// pids lists process IDs.
+func (fs procfs) pids() ([]int, error) {
+	entries, err := os.ReadDir(string(fs))
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// uptime returns the system uptime in seconds.
+func (fs procfs) uptime() (float64, error) {
+	data, err := os.ReadFile(fs.path("uptime"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errInvalidUptime
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+func (fs procfs) comm(pid int) (string, error) {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// cmdline returns the command line joined with spaces.
+func (fs procfs) cmdline(pid int) (string, error) {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	data = bytes.TrimRight(data, "\x00")
+	return string(bytes.ReplaceAll(data, []byte{0}, []byte{' '})), nil
+}
+
+// cgroups returns cgroup paths of the process.
+func (fs procfs) cgroups(pid int) ([]string, error) {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(sc.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		paths = append(paths, fields[2])
+	}
+	return paths, nil
+}
+
+// cpuTicks returns CPU time in clock ticks, and the start time
+// after system boot in clock ticks.
+func (fs procfs) cpuTicks(pid int) (ticks, start uint64, err error) {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// comm may contain spaces and parentheses.
+	i := bytes.LastIndexByte(data, ')')
+	if i == -1 {
+		return 0, 0, errInvalidStat
+	}
+	// fields from the third (state).
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return 0, 0, errInvalidStat
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	start, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return utime + stime, start, nil
+}
+
+// rss returns the resident set size in bytes.
+func (fs procfs) rss(pid int) (uint64, error) {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok || key != "VmRSS" {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+		kb, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb * 1024, nil
+	}
+	// kernel threads have no VmRSS.
+	return 0, errNoVmRSS
+}
+
+// fds returns the number of open file descriptors.
+func (fs procfs) fds(pid int) (int, error) {
+	entries, err := os.ReadDir(fs.path(strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,28 @@
+/*
+Package redis implements "redis" probe type that tests Redis servers.
+
+If field is not given, the probe sends PING and the value of the probe
+will be the time in seconds taken to connect, authenticate, and receive
+the reply.
+
+If field is given, the probe sends INFO and the value of the probe will
+be the numeric value of the field, e.g. "connected_clients" or
+"evicted_keys".  Fields whose value is a comma-separated list of
+key=value pairs can be specified as "field.key", for example
+"db0.keys" or "slave0.lag" for the replication lag of a replica.
+
+errval is returned if the probe fails to communicate with the server,
+the server replies an error, or the field is not found or not numeric.
+
+The constructor takes these parameters:
+
+	Name      Type     Default   Description
+	address   string             host:port of the server.  Required.
+	                             The port defaults to 6379.
+	user      string             User name for AUTH (Redis 6+).  Optional.
+	password  string             Password for AUTH.  Optional.
+	field     string             INFO field.  Optional.
+	section   string             INFO section, e.g. "replication".  Optional.
+	errval    float64  -1        Return value upon an error.
+*/
+package redis
@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultPort   = "6379"
+	defaultErrval = -1.0
+)
+
+type probe struct {
+	address  string
+	user     string
+	password string
+	field    string
+	section  string
+	errval   float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+	call := func(args ...string) (string, error) {
+		if err := writeCommand(conn, args...); err != nil {
+			return "", err
+		}
+		return readReply(r)
+	}
+
+	if len(p.password) > 0 {
+		args := []string{"AUTH", p.password}
+		if len(p.user) > 0 {
+			args = []string{"AUTH", p.user, p.password}
+		}
+		if _, err := call(args...); err != nil {
+			return p.fail(err)
+		}
+	}
+
+	if len(p.field) == 0 {
+		if _, err := call("PING"); err != nil {
+			return p.fail(err)
+		}
+		return time.Since(start).Seconds()
+	}
+
+	args := []string{"INFO"}
+	if len(p.section) > 0 {
+		args = append(args, p.section)
+	}
+	info, err := call(args...)
+	if err != nil {
+		return p.fail(err)
+	}
+	v, err := infoField(info, p.field)
+	if err != nil {
+		return p.fail(err)
+	}
+	return v
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:redis error", map[string]interface{}{
+		"address": p.address,
+		"field":   p.field,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	if len(p.field) > 0 {
+		return fmt.Sprintf("probe:redis:%s:%s", p.address, p.field)
+	}
+	return "probe:redis:" + p.address
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+
+	user, err := goma.GetString("user", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	password, err := goma.GetString("password", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if len(user) > 0 && len(password) == 0 {
+		return nil, errors.New("user requires password")
+	}
+
+	field, err := goma.GetString("field", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	section, err := goma.GetString("section", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:  address,
+		user:     user,
+		password: password,
+		field:    field,
+		section:  section,
+		errval:   errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("redis", construct)
+}
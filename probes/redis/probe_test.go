@@ -0,0 +1,247 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+const (
+	testInfo = "# Server\r\n" +
+		"redis_version:7.0.0\r\n" +
+		"\r\n" +
+		"# Clients\r\n" +
+		"connected_clients:12\r\n" +
+		"\r\n" +
+		"# Stats\r\n" +
+		"evicted_keys:3\r\n" +
+		"\r\n" +
+		"# Replication\r\n" +
+		"role:master\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=100,lag=2\r\n" +
+		"\r\n" +
+		"# Keyspace\r\n" +
+		"db0:keys=42,expires=1,avg_ttl=0\r\n"
+)
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		s, err := readReply(r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = s
+	}
+	return args, nil
+}
+
+// serve emulates a Redis server.  If password is not empty,
+// commands other than AUTH require authentication.
+func serve(l net.Listener, password string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			r := bufio.NewReader(c)
+			authenticated := len(password) == 0
+			for {
+				args, err := readCommand(r)
+				if err != nil {
+					return
+				}
+				cmd := strings.ToUpper(args[0])
+				switch {
+				case cmd == "AUTH":
+					if args[len(args)-1] != password {
+						io.WriteString(c, "-WRONGPASS invalid password\r\n")
+						continue
+					}
+					if len(args) == 3 && args[1] != "goma" {
+						io.WriteString(c, "-WRONGPASS invalid username\r\n")
+						continue
+					}
+					authenticated = true
+					io.WriteString(c, "+OK\r\n")
+				case !authenticated:
+					io.WriteString(c, "-NOAUTH Authentication required.\r\n")
+				case cmd == "PING":
+					io.WriteString(c, "+PONG\r\n")
+				case cmd == "INFO":
+					info := testInfo
+					if len(args) > 1 && args[1] == "sleep" {
+						time.Sleep(10 * time.Second)
+						return
+					}
+					if len(args) > 1 && args[1] == "replication" {
+						info = "# Replication\r\nrole:master\r\n"
+					}
+					io.WriteString(c, "$"+strconv.Itoa(len(info))+"\r\n"+info+"\r\n")
+				default:
+					io.WriteString(c, "-ERR unknown command\r\n")
+				}
+			}
+		}(conn)
+	}
+}
+
+func testServer(t *testing.T, password string) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go serve(l, password)
+	return l.Addr().String()
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": "localhost",
+		"user":    "goma",
+	}); err == nil {
+		t.Error("user without password should be rejected")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"address": "localhost",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).address != "localhost:6379" {
+		t.Error(`p.(*probe).address != "localhost:6379"`)
+	}
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, "")
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+	f = testProbe(t, map[string]interface{}{
+		"address": closed,
+		"errval":  100.0,
+	})
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, "secret")
+	cases := []struct {
+		params   map[string]interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"address": addr}, false},
+		{map[string]interface{}{"address": addr, "password": "secret"}, true},
+		{map[string]interface{}{"address": addr, "password": "wrong"}, false},
+		{map[string]interface{}{"address": addr, "user": "goma", "password": "secret"}, true},
+		{map[string]interface{}{"address": addr, "user": "other", "password": "secret"}, false},
+	}
+	for _, c := range cases {
+		f := testProbe(t, c.params)
+		if (f >= 0) != c.expected {
+			t.Error(c.params, f)
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, "")
+	cases := []struct {
+		field    string
+		section  string
+		expected float64
+	}{
+		{"connected_clients", "", 12},
+		{"evicted_keys", "", 3},
+		{"slave0.lag", "", 2},
+		{"db0.keys", "", 42},
+		{"db0.nosuchkey", "", -1},
+		{"role", "", -1},
+		{"nosuchfield", "", -1},
+		{"connected_clients", "replication", -1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, map[string]interface{}{
+			"address": addr,
+			"field":   c.field,
+			"section": c.section,
+		})
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.field, c.section, f, "!=", c.expected)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, "")
+	start := time.Now()
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+		"field":   "connected_clients",
+		"section": "sleep",
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxBulkSize limits the size of bulk string replies.
+	maxBulkSize = 16 * 1024 * 1024
+)
+
+var (
+	errNilReply = errors.New("nil reply")
+)
+
+// replyError is an error reply from the server.
+type replyError string
+
+func (e replyError) Error() string {
+	return string(e)
+}
+
+// writeCommand encodes a command as an array of bulk strings.
+func writeCommand(w io.Writer, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// readReply reads a simple string, an integer, or a bulk string reply.
+// Error replies are returned as replyError.
+func readReply(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", replyError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid bulk length: %s", line)
+		}
+		if n < 0 {
+			return "", errNilReply
+		}
+		if n > maxBulkSize {
+			return "", fmt.Errorf("too large bulk string: %d", n)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+	return "", fmt.Errorf("unsupported reply: %s", line)
+}
+
+// infoField finds a numeric field in the reply of INFO.
+// field may be "name" or "name.key" to look up key=value lists.
+func infoField(info, field string) (float64, error) {
+	name, key := field, ""
+	if i := strings.IndexByte(field, '.'); i >= 0 {
+		name, key = field[:i], field[i+1:]
+	}
+
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok || k != name {
+			continue
+		}
+		if len(key) == 0 {
+			return strconv.ParseFloat(v, 64)
+		}
+		for _, kv := range strings.Split(v, ",") {
+			k2, v2, ok := strings.Cut(kv, "=")
+			if ok && k2 == key {
+				return strconv.ParseFloat(v2, 64)
+			}
+		}
+		break
+	}
+	return 0, fmt.Errorf("field not found: %s", field)
+}
@@ -0,0 +1,35 @@
+/*
+Package smtp implements "smtp" probe type that tests SMTP servers.
+
+The probe receives the greeting, sends EHLO, then optionally
+negotiates TLS by STARTTLS and authenticates with AUTH PLAIN.
+Finally it sends QUIT.
+
+If mode is "latency", the value of the probe will be the time in
+seconds taken to complete the conversation.  If mode is "status",
+the value will be 0.  If any step fails, errval is returned.
+
+AUTH PLAIN is refused over unencrypted connections unless the server
+is localhost.  Use starttls or tls to authenticate remote servers.
+
+The constructor takes these parameters:
+
+	Name                  Type     Default   Description
+	address               string             host:port of the server.  Required.
+	                                         The port defaults to 25.
+	helo                  string   (*)       Host name sent with EHLO.
+	tls                   bool     false     If true, connect with TLS (SMTPS).
+	starttls              bool     false     If true, STARTTLS is required.
+	server_name           string             Server name for TLS.
+	                                         Defaults to the host of address.
+	insecure_skip_verify  bool     false     If true, skip TLS certificate checks.
+	user                  string             User name for AUTH.  Optional.
+	password              string             Password for AUTH.
+	mode                  string   latency   "latency" or "status".
+	errval                float64  (**)      Return value upon an error.
+
+(*) helo defaults to the host name of the local machine.
+
+(**) errval defaults to -1 for "latency" and 1 for "status".
+*/
+package smtp
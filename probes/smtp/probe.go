@@ -0,0 +1,234 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	modeLatency = "latency"
+	modeStatus  = "status"
+
+	defaultPort          = "25"
+	defaultLatencyErrval = -1.0
+	defaultStatusErrval  = 1.0
+)
+
+var (
+	errNoStartTLS = errors.New("server does not support STARTTLS")
+	errNoAuth     = errors.New("server does not support AUTH")
+)
+
+type probe struct {
+	address   string
+	host      string
+	helo      string
+	tls       bool
+	starttls  bool
+	tlsConfig *tls.Config
+	auth      smtp.Auth
+	mode      string
+	errval    float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if p.tls {
+		tc := tls.Client(conn, p.tlsConfig)
+		if err := tc.Handshake(); err != nil {
+			return p.fail(err)
+		}
+		conn = tc
+	}
+
+	if err := p.converse(conn); err != nil {
+		return p.fail(err)
+	}
+
+	if p.mode == modeStatus {
+		return 0
+	}
+	return time.Since(start).Seconds()
+}
+
+func (p *probe) converse(conn net.Conn) error {
+	c, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		return err
+	}
+	if err := c.Hello(p.helo); err != nil {
+		return err
+	}
+
+	// Extension sends EHLO at the first call.
+	ok, _ := c.Extension("STARTTLS")
+	if p.starttls {
+		if !ok {
+			return errNoStartTLS
+		}
+		if err := c.StartTLS(p.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if p.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errNoAuth
+		}
+		if err := c.Auth(p.auth); err != nil {
+			return err
+		}
+	}
+
+	return c.Quit()
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:smtp error", map[string]interface{}{
+		"address": p.address,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return "probe:smtp:" + p.address
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	helo, err := goma.GetString("helo", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		helo, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	useTLS, err := goma.GetBool("tls", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	starttls, err := goma.GetBool("starttls", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if useTLS && starttls {
+		return nil, errors.New("tls and starttls are exclusive")
+	}
+	serverName, err := goma.GetString("server_name", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		serverName = host
+	default:
+		return nil, err
+	}
+	insecure, err := goma.GetBool("insecure_skip_verify", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	user, err := goma.GetString("user", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	password, err := goma.GetString("password", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	var auth smtp.Auth
+	if len(user) > 0 {
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		if mode != modeLatency && mode != modeStatus {
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeLatency
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultLatencyErrval
+		if mode == modeStatus {
+			errval = defaultStatusErrval
+		}
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:  address,
+		host:     host,
+		helo:     helo,
+		tls:      useTLS,
+		starttls: starttls,
+		tlsConfig: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: insecure,
+		},
+		auth:   auth,
+		mode:   mode,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("smtp", construct)
+}
@@ -0,0 +1,252 @@
+package smtp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+// testTLSConfig returns a server config with a self-signed certificate.
+func testTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+		}},
+	}
+}
+
+func testServer(t *testing.T, s *server) string {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go s.serve(l)
+	return l.Addr().String()
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address":  "localhost",
+		"tls":      true,
+		"starttls": true,
+	}); err == nil {
+		t.Error("tls and starttls should be exclusive")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": "localhost",
+		"mode":    "count",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"address": "localhost",
+		"mode":    "status",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).address != "localhost:25" {
+		t.Error(`p.(*probe).address != "localhost:25"`)
+	}
+	if !goma.FloatEquals(p.(*probe).errval, 1) {
+		t.Error(`!goma.FloatEquals(p.(*probe).errval, 1)`)
+	}
+}
+
+func TestEHLO(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, &server{})
+	f := testProbe(t, map[string]interface{}{
+		"address": addr,
+	})
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"address": addr,
+		"mode":    "status",
+	})
+	if f != 0 {
+		t.Error(`f != 0`)
+	}
+
+	addr = testServer(t, &server{greeting: "554 no service"})
+	f = testProbe(t, map[string]interface{}{
+		"address": addr,
+		"mode":    "status",
+	})
+	if !goma.FloatEquals(f, 1) {
+		t.Error(`!goma.FloatEquals(f, 1)`)
+	}
+}
+
+func TestStartTLS(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, &server{tlsConfig: testTLSConfig(t)})
+	cases := []struct {
+		params   map[string]interface{}
+		expected float64
+	}{
+		{map[string]interface{}{"starttls": true}, -1},
+		{map[string]interface{}{"starttls": true, "insecure_skip_verify": true}, 0},
+		{map[string]interface{}{"starttls": false}, 0},
+	}
+	for _, c := range cases {
+		c.params["address"] = addr
+		c.params["mode"] = "status"
+		c.params["errval"] = -1.0
+		f := testProbe(t, c.params)
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.params, f, "!=", c.expected)
+		}
+	}
+
+	addr = testServer(t, &server{})
+	f := testProbe(t, map[string]interface{}{
+		"address":              addr,
+		"starttls":             true,
+		"insecure_skip_verify": true,
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error("STARTTLS should be required")
+	}
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	l, err := tls.Listen("tcp", "localhost:0", testTLSConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := &server{}
+	go s.serve(l)
+
+	f := testProbe(t, map[string]interface{}{
+		"address":              l.Addr().String(),
+		"tls":                  true,
+		"insecure_skip_verify": true,
+	})
+	if f < 0 {
+		t.Error(`f < 0`)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	addr := testServer(t, &server{
+		tlsConfig: testTLSConfig(t),
+		user:      "goma",
+		password:  "secret",
+	})
+	cases := []struct {
+		params   map[string]interface{}
+		expected float64
+	}{
+		{map[string]interface{}{"user": "goma", "password": "secret"}, 0},
+		{map[string]interface{}{"user": "goma", "password": "wrong"}, 1},
+		{map[string]interface{}{"user": "goma", "password": "secret",
+			"starttls": true, "insecure_skip_verify": true}, 0},
+	}
+	for _, c := range cases {
+		c.params["address"] = addr
+		c.params["mode"] = "status"
+		f := testProbe(t, c.params)
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.params, f, "!=", c.expected)
+		}
+	}
+
+	addr = testServer(t, &server{})
+	f := testProbe(t, map[string]interface{}{
+		"address":  addr,
+		"user":     "goma",
+		"password": "secret",
+		"mode":     "status",
+	})
+	if !goma.FloatEquals(f, 1) {
+		t.Error("server without AUTH should fail")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// accept but never greet.
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(10 * time.Second)
+	}()
+
+	start := time.Now()
+	f := testProbe(t, map[string]interface{}{
+		"address": l.Addr().String(),
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
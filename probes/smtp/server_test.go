@@ -0,0 +1,145 @@
+// mockup SMTP server derived from actions/mail.
+//
+// Only for testing purpose.
+
+package smtp
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+type server struct {
+	// tlsConfig enables STARTTLS if not nil.
+	tlsConfig *tls.Config
+
+	// user and password enable AUTH PLAIN if user is not empty.
+	user     string
+	password string
+
+	// greeting replaces the default greeting if not empty.
+	greeting string
+}
+
+func (s *server) serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.process(conn)
+	}
+}
+
+func (s *server) process(c net.Conn) {
+	tc := textproto.NewConn(c)
+	defer func() {
+		tc.Close()
+	}()
+
+	doneHello := false
+	doneTLS := false
+	if _, ok := c.(*tls.Conn); ok {
+		doneTLS = true
+	}
+
+	reply := func(code int, msg string, cnt bool) error {
+		delim := " "
+		if cnt {
+			delim = "-"
+		}
+		return tc.Writer.PrintfLine("%d%s%s", code, delim, msg)
+	}
+
+	greeting := s.greeting
+	if len(greeting) == 0 {
+		greeting = "220 localhost goma test mail server"
+	}
+	if tc.Writer.PrintfLine("%s", greeting) != nil {
+		return
+	}
+
+	for {
+		l, err := tc.Reader.ReadLine()
+		if err != nil {
+			return
+		}
+		ul := strings.ToUpper(l)
+
+		switch {
+		case ul == "NOOP" || strings.HasPrefix(ul, "NOOP "):
+			if reply(250, "OK", false) != nil {
+				return
+			}
+		case ul == "QUIT":
+			reply(221, "OK", false)
+			return
+		case strings.HasPrefix(ul, "EHLO "):
+			if doneHello {
+				if reply(503, "Duplicate HELO/EHLO", false) != nil {
+					return
+				}
+				continue
+			}
+			if reply(250, "localhost greets you", true) != nil {
+				return
+			}
+			if s.tlsConfig != nil && !doneTLS {
+				if reply(250, "STARTTLS", true) != nil {
+					return
+				}
+			}
+			if len(s.user) > 0 {
+				if reply(250, "AUTH PLAIN", true) != nil {
+					return
+				}
+			}
+			if reply(250, "8BITMIME", false) != nil {
+				return
+			}
+			doneHello = true
+		case ul == "STARTTLS":
+			if s.tlsConfig == nil || doneTLS {
+				if reply(502, "command not implemented", false) != nil {
+					return
+				}
+				continue
+			}
+			if reply(220, "ready to start TLS", false) != nil {
+				return
+			}
+			tlsConn := tls.Server(c, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			c = tlsConn
+			tc = textproto.NewConn(c)
+			doneHello = false
+			doneTLS = true
+		case strings.HasPrefix(ul, "AUTH PLAIN "):
+			if len(s.user) == 0 || !doneHello {
+				if reply(503, "bad sequence of commands", false) != nil {
+					return
+				}
+				continue
+			}
+			resp, err := base64.StdEncoding.DecodeString(l[len("AUTH PLAIN "):])
+			if err != nil || string(resp) != "\x00"+s.user+"\x00"+s.password {
+				if reply(535, "authentication failed", false) != nil {
+					return
+				}
+				continue
+			}
+			if reply(235, "authentication succeeded", false) != nil {
+				return
+			}
+		default:
+			if reply(500, "unknown command", false) != nil {
+				return
+			}
+		}
+	}
+}
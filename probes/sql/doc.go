@@ -0,0 +1,36 @@
+/*
+Package sql implements "sql" probe type that test databases through
+database/sql drivers.
+
+The probe runs a SELECT query and returns a value determined by
+column and count parameters:
+
+  - By default, the value of a scalar query that returns a single column.
+  - If column is given, the value of the named column of the first row.
+  - If count is true, the number of rows returned from the query.
+
+The value should be a floating point value.  NULL is an error.
+
+The query runs with the probe's context, so drivers that support
+context cancellation stop the query when the deadline expires.
+
+The constructor takes these parameters:
+
+	Name       Type     Default   Description
+	driver     string             database/sql driver name.  Required.
+	dsn        string             DSN for the database.  Required.
+	query      string             SELECT statement.  Required.
+	column     string             Column name to be the value.
+	count      bool     false     If true, return the number of rows.
+	errval     float64  0         Return value upon an error.
+
+The drivers of probes/mysql ("mysql") and probes/postgresql ("postgres")
+are available in the standard build.  Other drivers can be added by
+importing them in a custom build, for example:
+
+	import (
+		_ "github.com/cybozu-go/goma/probes/all"
+		_ "github.com/mattn/go-sqlite3"
+	)
+*/
+package sql
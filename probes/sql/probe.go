@@ -0,0 +1,183 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+var (
+	errNoRows    = errors.New("no rows in result set")
+	errNoColumn  = errors.New("no such column")
+	errBothModes = errors.New("column and count are exclusive")
+)
+
+type probe struct {
+	driver string
+	dsn    string
+	db     *sql.DB
+	query  string
+	column string
+	count  bool
+	errval float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	var v float64
+	var err error
+	switch {
+	case p.count:
+		v, err = p.countRows(ctx)
+	case len(p.column) > 0:
+		v, err = p.columnValue(ctx)
+	default:
+		err = p.db.QueryRowContext(ctx, p.query).Scan(&v)
+	}
+	if err != nil {
+		log.Error("probe:sql error", map[string]interface{}{
+			"driver": p.driver,
+			"dsn":    p.dsn,
+			"error":  err.Error(),
+		})
+		return p.errval
+	}
+	return v
+}
+
+func (p *probe) countRows(ctx context.Context) (float64, error) {
+	rows, err := p.db.QueryContext(ctx, p.query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return float64(n), nil
+}
+
+func (p *probe) columnValue(ctx context.Context) (float64, error) {
+	rows, err := p.db.QueryContext(ctx, p.query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	index := -1
+	for i, c := range columns {
+		if c == p.column {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return 0, fmt.Errorf("%v: %s", errNoColumn, p.column)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errNoRows
+	}
+
+	var v float64
+	dest := make([]interface{}, len(columns))
+	for i := range dest {
+		if i == index {
+			dest[i] = &v
+			continue
+		}
+		dest[i] = new(interface{})
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("sql:%s:%s:%s", p.driver, p.dsn, p.query)
+}
+
+// Close closes the connection pool.
+func (p *probe) Close() error {
+	return p.db.Close()
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	driver, err := goma.GetString("driver", params)
+	if err != nil {
+		return nil, err
+	}
+	drivers := sql.Drivers()
+	i := sort.SearchStrings(drivers, driver)
+	if i == len(drivers) || drivers[i] != driver {
+		return nil, fmt.Errorf("unknown driver: %s (available: %s)",
+			driver, strings.Join(drivers, ", "))
+	}
+
+	dsn, err := goma.GetString("dsn", params)
+	if err != nil {
+		return nil, err
+	}
+	query, err := goma.GetString("query", params)
+	if err != nil {
+		return nil, err
+	}
+	column, err := goma.GetString("column", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	count, err := goma.GetBool("count", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	if count && len(column) > 0 {
+		return nil, errBothModes
+	}
+	errval, err := goma.GetFloat("errval", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &probe{
+		driver: driver,
+		dsn:    dsn,
+		db:     db,
+		query:  query,
+		column: column,
+		count:  count,
+		errval: errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("sql", construct)
+}
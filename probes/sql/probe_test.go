@@ -0,0 +1,261 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+const (
+	testDriver = "gomafake"
+)
+
+// fakeDriver returns fixed results for these queries:
+//
+//	SELECT 1       one row with a column
+//	SELECT queue   three rows with id, name, and depth columns
+//	SELECT empty   no rows
+//	SELECT null    one row with NULL
+//	SELECT sleep   waits until the context is done
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	switch query {
+	case "SELECT 1":
+		return &fakeRows{
+			columns: []string{"?column?"},
+			values:  [][]driver.Value{{int64(1)}},
+		}, nil
+	case "SELECT queue":
+		return &fakeRows{
+			columns: []string{"id", "name", "depth"},
+			values: [][]driver.Value{
+				{int64(1), "a", 10.5},
+				{int64(2), "b", 20.0},
+				{int64(3), "c", 30.0},
+			},
+		}, nil
+	case "SELECT empty":
+		return &fakeRows{columns: []string{"depth"}}, nil
+	case "SELECT null":
+		return &fakeRows{
+			columns: []string{"v"},
+			values:  [][]driver.Value{{nil}},
+		}, nil
+	case "SELECT sleep":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, errors.New("syntax error")
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	sql.Register(testDriver, fakeDriver{})
+	os.Exit(m.Run())
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	params["driver"] = testDriver
+	params["dsn"] = "fake"
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"driver": "nosuchdriver",
+		"dsn":    "fake",
+		"query":  "SELECT 1",
+	}); err == nil {
+		t.Error("unknown driver should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"driver": testDriver,
+		"dsn":    "fake",
+	}); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"driver": testDriver,
+		"dsn":    "fake",
+		"query":  "SELECT queue",
+		"column": "depth",
+		"count":  true,
+	}); err == nil {
+		t.Error("column and count should be exclusive")
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"query": "SELECT 1",
+	})
+	if !goma.FloatEquals(v, 1) {
+		t.Error(`!goma.FloatEquals(v, 1)`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query":  "SELECT queue",
+		"errval": 123,
+	})
+	if !goma.FloatEquals(v, 123) {
+		t.Error("multiple columns need column parameter")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"query":  "SELECT queue",
+		"column": "depth",
+	})
+	if !goma.FloatEquals(v, 10.5) {
+		t.Error(`!goma.FloatEquals(v, 10.5)`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query":  "SELECT queue",
+		"column": "nosuchcolumn",
+		"errval": 123,
+	})
+	if !goma.FloatEquals(v, 123) {
+		t.Error(`no column: !goma.FloatEquals(v, 123)`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query":  "SELECT empty",
+		"column": "depth",
+		"errval": 123,
+	})
+	if !goma.FloatEquals(v, 123) {
+		t.Error(`no rows: !goma.FloatEquals(v, 123)`)
+	}
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"query": "SELECT queue",
+		"count": true,
+	})
+	if !goma.FloatEquals(v, 3) {
+		t.Error(`!goma.FloatEquals(v, 3)`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query": "SELECT empty",
+		"count": true,
+	})
+	if !goma.FloatEquals(v, 0) {
+		t.Error(`!goma.FloatEquals(v, 0)`)
+	}
+}
+
+func TestErrval(t *testing.T) {
+	t.Parallel()
+
+	v := testProbe(t, map[string]interface{}{
+		"query": "SELECT hogenotfound()",
+	})
+	if v != 0 {
+		t.Error(`v != 0`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query":  "SELECT null",
+		"errval": 123,
+	})
+	if !goma.FloatEquals(v, 123) {
+		t.Error(`NULL: !goma.FloatEquals(v, 123)`)
+	}
+
+	v = testProbe(t, map[string]interface{}{
+		"query":  "SELECT empty",
+		"errval": 123,
+	})
+	if !goma.FloatEquals(v, 123) {
+		t.Error(`no rows: !goma.FloatEquals(v, 123)`)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	v := testProbe(t, map[string]interface{}{
+		"query":  "SELECT sleep",
+		"errval": 123.45,
+	})
+	if !goma.FloatEquals(v, 123.45) {
+		t.Error(`!goma.FloatEquals(v, 123.45)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
@@ -0,0 +1,71 @@
+//go:build linux
+
+package systemd
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdDest      = "org.freedesktop.systemd1"
+	systemdPath      = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerInterface = "org.freedesktop.systemd1.Manager"
+	unitInterface    = "org.freedesktop.systemd1.Unit"
+	serviceInterface = "org.freedesktop.systemd1.Service"
+	propertiesGetAll = "org.freedesktop.DBus.Properties.GetAll"
+	propertiesGet    = "org.freedesktop.DBus.Properties.Get"
+)
+
+// busConn is the subset of D-Bus connection used by the probe.
+// This is replaced with a fake in tests.
+type busConn interface {
+	// Call calls a method of the object at path of systemd,
+	// and stores the reply in ret.
+	Call(ctx context.Context, path dbus.ObjectPath, method string, args []interface{}, ret ...interface{}) error
+
+	// Close closes the connection.
+	Close() error
+}
+
+type systemBus struct {
+	conn *dbus.Conn
+}
+
+// dialSystemBus connects to the system bus.
+func dialSystemBus(ctx context.Context) (busConn, error) {
+	type result struct {
+		conn *dbus.Conn
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := dbus.ConnectSystemBus()
+		ch <- result{conn, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-ch; r.err == nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	case r := <-ch:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return systemBus{r.conn}, nil
+	}
+}
+
+func (b systemBus) Call(ctx context.Context, path dbus.ObjectPath, method string, args []interface{}, ret ...interface{}) error {
+	return b.conn.Object(systemdDest, path).CallWithContext(ctx, method, 0, args...).Store(ret...)
+}
+
+func (b systemBus) Close() error {
+	return b.conn.Close()
+}
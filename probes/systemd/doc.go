@@ -0,0 +1,29 @@
+/*
+Package systemd implements "systemd" probe type that checks systemd
+units through D-Bus.
+
+If mode is "state", the value of the probe will be 0 if ActiveState
+of the unit is active_state and SubState is sub_state (if given),
+or 1.0 otherwise.
+
+If mode is "restarts", the value of the probe will be NRestarts of
+the service unit, that is the number of automatic restarts by systemd.
+NRestarts is available since systemd 235.
+
+If the probe fails to communicate with systemd, errval is returned.
+
+This probe is available only on Linux.
+
+The constructor takes these parameters:
+
+	Name          Type     Default   Description
+	unit          string             Unit name.  Required.
+	                                 ".service" is appended if no suffix.
+	mode          string   state     "state" or "restarts".
+	active_state  string   active    Expected ActiveState.
+	sub_state     string             Expected SubState.  Optional.
+	errval        float64  (*)       Return value upon an error.
+
+(*) errval defaults to 1 for "state" and -1 for "restarts".
+*/
+package systemd
@@ -0,0 +1,198 @@
+//go:build linux
+
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	modeState    = "state"
+	modeRestarts = "restarts"
+
+	defaultActiveState    = "active"
+	defaultStateErrval    = 1.0
+	defaultRestartsErrval = -1.0
+)
+
+var (
+	errInvalidProperty = errors.New("invalid property")
+	errNotService      = errors.New("restarts mode needs a service unit")
+)
+
+type probe struct {
+	unit        string
+	mode        string
+	activeState string
+	subState    string
+	errval      float64
+
+	// dial can be replaced for tests.
+	dial func(context.Context) (busConn, error)
+
+	lock sync.Mutex
+	conn busConn
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.conn == nil {
+		conn, err := p.dial(ctx)
+		if err != nil {
+			return p.fail(err)
+		}
+		p.conn = conn
+	}
+
+	v, err := p.probe(ctx)
+	if err != nil {
+		// reconnect next time as the connection may be broken.
+		p.conn.Close()
+		p.conn = nil
+		return p.fail(err)
+	}
+	return v
+}
+
+func (p *probe) probe(ctx context.Context) (float64, error) {
+	// LoadUnit returns the object of units that are not active.
+	var path dbus.ObjectPath
+	err := p.conn.Call(ctx, systemdPath, managerInterface+".LoadUnit",
+		[]interface{}{p.unit}, &path)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.mode == modeRestarts {
+		var v dbus.Variant
+		err := p.conn.Call(ctx, path, propertiesGet,
+			[]interface{}{serviceInterface, "NRestarts"}, &v)
+		if err != nil {
+			return 0, err
+		}
+		n, ok := v.Value().(uint32)
+		if !ok {
+			return 0, fmt.Errorf("%v: NRestarts=%s", errInvalidProperty, v.String())
+		}
+		return float64(n), nil
+	}
+
+	var props map[string]dbus.Variant
+	err = p.conn.Call(ctx, path, propertiesGetAll,
+		[]interface{}{unitInterface}, &props)
+	if err != nil {
+		return 0, err
+	}
+	activeState, _ := props["ActiveState"].Value().(string)
+	subState, _ := props["SubState"].Value().(string)
+
+	if activeState != p.activeState {
+		return 1.0, nil
+	}
+	if len(p.subState) > 0 && subState != p.subState {
+		return 1.0, nil
+	}
+	return 0, nil
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:systemd error", map[string]interface{}{
+		"unit":  p.unit,
+		"error": err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return fmt.Sprintf("probe:systemd:%s:%s", p.unit, p.mode)
+}
+
+// Close closes the connection to the system bus.
+func (p *probe) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	unit, err := goma.GetString("unit", params)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+
+	mode, err := goma.GetString("mode", params)
+	switch err {
+	case nil:
+		switch mode {
+		case modeState:
+		case modeRestarts:
+			if !strings.HasSuffix(unit, ".service") {
+				return nil, errNotService
+			}
+		default:
+			return nil, fmt.Errorf("invalid mode: %s", mode)
+		}
+	case goma.ErrNoKey:
+		mode = modeState
+	default:
+		return nil, err
+	}
+
+	activeState, err := goma.GetString("active_state", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		activeState = defaultActiveState
+	default:
+		return nil, err
+	}
+	subState, err := goma.GetString("sub_state", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultStateErrval
+		if mode == modeRestarts {
+			errval = defaultRestartsErrval
+		}
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		unit:        unit,
+		mode:        mode,
+		activeState: activeState,
+		subState:    subState,
+		errval:      errval,
+		dial:        dialSystemBus,
+	}, nil
+}
+
+func init() {
+	probes.Register("systemd", construct)
+}
@@ -0,0 +1,235 @@
+//go:build linux
+
+package systemd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/godbus/dbus/v5"
+)
+
+type fakeUnit struct {
+	activeState string
+	subState    string
+	restarts    uint32
+}
+
+// fakeBus emulates systemd on D-Bus.
+type fakeBus struct {
+	mu     sync.Mutex
+	units  map[string]fakeUnit
+	broken bool
+	dials  int
+	closed int
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{
+		units: map[string]fakeUnit{
+			"nginx.service":   {"active", "running", 0},
+			"backup.service":  {"inactive", "dead", 0},
+			"flappy.service":  {"active", "running", 3},
+			"backup.timer":    {"active", "waiting", 0},
+			"oneshot.service": {"active", "exited", 0},
+		},
+	}
+}
+
+func (b *fakeBus) dial(ctx context.Context) (busConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dials++
+	return b, nil
+}
+
+func unitPath(name string) dbus.ObjectPath {
+	return dbus.ObjectPath("/org/freedesktop/systemd1/unit/" + name)
+}
+
+func (b *fakeBus) Call(ctx context.Context, path dbus.ObjectPath, method string, args []interface{}, ret ...interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.broken {
+		return errors.New("connection closed")
+	}
+
+	switch method {
+	case managerInterface + ".LoadUnit":
+		*ret[0].(*dbus.ObjectPath) = unitPath(args[0].(string))
+		return nil
+	case propertiesGetAll, propertiesGet:
+	default:
+		return errors.New("unknown method")
+	}
+
+	// LoadUnit succeeds for unknown units with LoadState=not-found.
+	u, ok := fakeUnit{"inactive", "dead", 0}, false
+	for name, unit := range b.units {
+		if unitPath(name) == path {
+			u, ok = unit, true
+		}
+	}
+
+	if method == propertiesGetAll {
+		if args[0] != unitInterface {
+			return errors.New("unknown interface")
+		}
+		*ret[0].(*map[string]dbus.Variant) = map[string]dbus.Variant{
+			"ActiveState": dbus.MakeVariant(u.activeState),
+			"SubState":    dbus.MakeVariant(u.subState),
+		}
+		return nil
+	}
+
+	if !ok || args[0] != serviceInterface || args[1] != "NRestarts" {
+		return errors.New("unknown property")
+	}
+	*ret[0].(*dbus.Variant) = dbus.MakeVariant(u.restarts)
+	return nil
+}
+
+func (b *fakeBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed++
+	return nil
+}
+
+func testProbe(t *testing.T, b *fakeBus, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.(*probe).dial = b.dial
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"unit": "nginx",
+		"mode": "memory",
+	}); err == nil {
+		t.Error("invalid mode should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"unit": "backup.timer",
+		"mode": "restarts",
+	}); err != errNotService {
+		t.Error(`err != errNotService`)
+	}
+
+	p, err := construct(map[string]interface{}{
+		"unit": "nginx",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*probe).unit != "nginx.service" {
+		t.Error(`p.(*probe).unit != "nginx.service"`)
+	}
+}
+
+func TestState(t *testing.T) {
+	t.Parallel()
+
+	b := newFakeBus()
+	cases := []struct {
+		params   map[string]interface{}
+		expected float64
+	}{
+		{map[string]interface{}{"unit": "nginx"}, 0},
+		{map[string]interface{}{"unit": "nginx", "sub_state": "running"}, 0},
+		{map[string]interface{}{"unit": "oneshot", "sub_state": "running"}, 1},
+		{map[string]interface{}{"unit": "backup"}, 1},
+		{map[string]interface{}{"unit": "backup", "active_state": "inactive"}, 0},
+		{map[string]interface{}{"unit": "backup.timer", "sub_state": "waiting"}, 0},
+		{map[string]interface{}{"unit": "nosuchunit"}, 1},
+	}
+	for _, c := range cases {
+		f := testProbe(t, b, c.params)
+		if !goma.FloatEquals(f, c.expected) {
+			t.Error(c.params, f, "!=", c.expected)
+		}
+	}
+}
+
+func TestRestarts(t *testing.T) {
+	t.Parallel()
+
+	b := newFakeBus()
+	f := testProbe(t, b, map[string]interface{}{
+		"unit": "flappy",
+		"mode": "restarts",
+	})
+	if !goma.FloatEquals(f, 3) {
+		t.Error(`!goma.FloatEquals(f, 3)`)
+	}
+
+	f = testProbe(t, b, map[string]interface{}{
+		"unit": "nosuchunit",
+		"mode": "restarts",
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	t.Parallel()
+
+	b := newFakeBus()
+	p, err := construct(map[string]interface{}{
+		"unit":   "nginx",
+		"errval": 100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.(*probe).dial = b.dial
+
+	ctx := context.Background()
+	if f := p.Probe(ctx); f != 0 {
+		t.Error(`f != 0`)
+	}
+	if f := p.Probe(ctx); f != 0 {
+		t.Error(`f != 0`)
+	}
+	if b.dials != 1 {
+		t.Error("connection is not reused:", b.dials)
+	}
+
+	b.mu.Lock()
+	b.broken = true
+	b.mu.Unlock()
+	if f := p.Probe(ctx); !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+	if b.closed != 1 {
+		t.Error("broken connection is not closed")
+	}
+
+	b.mu.Lock()
+	b.broken = false
+	b.mu.Unlock()
+	if f := p.Probe(ctx); f != 0 {
+		t.Error(`f != 0`)
+	}
+	if b.dials != 2 {
+		t.Error("probe does not reconnect:", b.dials)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Package tcp implements "tcp" probe type that tests TCP servers.
+
+The value of the probe will be the time in seconds taken to establish
+a connection (including TLS handshake if tls is true).  If the probe
+fails to connect, or the response does not match expect, errval is
+returned.
+
+If send is given, it is sent to the server after the connection is
+established.  If expect is given, the probe reads the response until
+it matches expect, which is a regular expression.
+
+The constructor takes these parameters:
+
+	Name                  Type     Default   Description
+	address               string             host:port to connect.  Required.
+	send                  string             String to send.  Optional.
+	expect                string             Regexp for the response.  Optional.
+	tls                   bool     false     If true, connect with TLS.
+	server_name           string             Server name for TLS.
+	                                         Defaults to the host of address.
+	insecure_skip_verify  bool     false     If true, skip TLS certificate checks.
+	errval                float64  -1        Return value upon an error.
+*/
+package tcp
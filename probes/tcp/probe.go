@@ -0,0 +1,187 @@
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultErrval = -1.0
+
+	// maxResponseSize limits the response size to be matched with expect.
+	maxResponseSize = 64 * 1024
+)
+
+var (
+	errNotMatched = errors.New("response does not match")
+)
+
+type probe struct {
+	address   string
+	send      string
+	expect    *regexp.Regexp
+	tlsConfig *tls.Config
+	errval    float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	start := time.Now()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if p.tlsConfig != nil {
+		tc := tls.Client(conn, p.tlsConfig)
+		if err := tc.Handshake(); err != nil {
+			return p.fail(err)
+		}
+		conn = tc
+	}
+	latency := time.Since(start)
+
+	if len(p.send) > 0 {
+		if _, err := conn.Write([]byte(p.send)); err != nil {
+			return p.fail(err)
+		}
+	}
+
+	if p.expect != nil {
+		if err := p.readExpect(conn); err != nil {
+			return p.fail(err)
+		}
+	}
+
+	return latency.Seconds()
+}
+
+// readExpect reads from conn until the response matches p.expect.
+func (p *probe) readExpect(conn net.Conn) error {
+	buf := make([]byte, 0, 4096)
+	for len(buf) < maxResponseSize {
+		if len(buf) == cap(buf) {
+			nb := make([]byte, len(buf), 2*cap(buf))
+			copy(nb, buf)
+			buf = nb
+		}
+		n, err := conn.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if p.expect.Match(buf) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return errNotMatched
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:tcp error", map[string]interface{}{
+		"address": p.address,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return "probe:tcp:" + p.address
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	send, err := goma.GetString("send", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	var expect *regexp.Regexp
+	expectString, err := goma.GetString("expect", params)
+	switch err {
+	case nil:
+		expect, err = regexp.Compile(expectString)
+		if err != nil {
+			return nil, err
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	useTLS, err := goma.GetBool("tls", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	serverName, err := goma.GetString("server_name", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		serverName = host
+	default:
+		return nil, err
+	}
+	insecure, err := goma.GetBool("insecure_skip_verify", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig = &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: insecure,
+		}
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:   address,
+		send:      send,
+		expect:    expect,
+		tlsConfig: tlsConfig,
+		errval:    errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("tcp", construct)
+}
@@ -0,0 +1,215 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+const (
+	testAddress = "localhost:13841"
+)
+
+// serve echoes lines.  "sleep" line makes the server sleep.
+func serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			r := bufio.NewReader(c)
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimSpace(line) == "sleep" {
+					time.Sleep(10 * time.Second)
+					return
+				}
+				c.Write([]byte(line))
+			}
+		}(conn)
+	}
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	l, err := net.Listen("tcp", testAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go serve(l)
+	os.Exit(m.Run())
+}
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 1*time.Second)
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": "localhost",
+	}); err == nil {
+		t.Error("address without port should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"expect":  "(",
+	}); err == nil {
+		t.Error("bad regexp should be rejected")
+	}
+
+	p, err := construct(map[string]interface{}{
+		"address": testAddress,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	f := p.Probe(ctx)
+	if f < 0 || f > 1 {
+		t.Error(`unexpected latency:`, f)
+	}
+}
+
+func TestRefused(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	p, err := construct(map[string]interface{}{
+		"address": addr,
+		"errval":  100.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error(`!goma.FloatEquals(f, 100.0)`)
+	}
+}
+
+func TestExpect(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"send":    "hello goma\n",
+		"expect":  "^hello [a-z]+",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	f := p.Probe(ctx)
+	if f < 0 {
+		t.Error(`f < 0`)
+	}
+
+	p, err = construct(map[string]interface{}{
+		"address": testAddress,
+		"send":    "hello goma\n",
+		"expect":  "^bye",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx2, cancel2 := testContext()
+	defer cancel2()
+	f = p.Probe(ctx2)
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	p, err := construct(map[string]interface{}{
+		"address": testAddress,
+		"send":    "sleep\n",
+		"expect":  ".",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := testContext()
+	defer cancel()
+	start := time.Now()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, -1) {
+		t.Error(`!goma.FloatEquals(f, -1)`)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("probe does not respect the deadline")
+	}
+}
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	addr := strings.TrimPrefix(s.URL, "https://")
+
+	p, err := construct(map[string]interface{}{
+		"address": addr,
+		"tls":     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	f := p.Probe(ctx)
+	if !goma.FloatEquals(f, -1) {
+		t.Error("untrusted certificate should be rejected")
+	}
+
+	p, err = construct(map[string]interface{}{
+		"address":              addr,
+		"tls":                  true,
+		"insecure_skip_verify": true,
+		"send":                 "GET / HTTP/1.0\r\n\r\n",
+		"expect":               `^HTTP/1\.[01] 200`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx2, cancel2 := testContext()
+	defer cancel2()
+	f = p.Probe(ctx2)
+	if f < 0 {
+		t.Error(`f < 0`)
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Package tls implements "tls" probe type that checks TLS certificates.
+
+The value of the probe will be the number of days until the earliest
+expiration among the certificates presented by the server.  The value
+is negative if a certificate has already expired.
+
+A monitor with "min = 14" fails two weeks before a certificate expires.
+
+If starttls is given, the probe negotiates TLS by the protocol's own
+command before the TLS handshake.  Supported protocols are "smtp",
+"imap", and "postgres".
+
+By default, the probe does not validate certificates.  If verify is
+true, the probe validates the chain and the host name against the
+system roots, or against the certificates in ca_file if given.
+
+If the probe fails to connect, the TLS handshake fails, or the
+validation fails, errval is returned.
+
+The constructor takes these parameters:
+
+	Name         Type     Default   Description
+	address      string             host:port to connect.  Required.
+	server_name  string             Server name for SNI and validation.
+	                                Defaults to the host of address.
+	starttls     string             "smtp", "imap", or "postgres".
+	verify       bool     false     If true, validate certificates.
+	ca_file      string             PEM file of CA certificates for verify.
+	                                Requires verify to be true.
+	errval       float64  -1        Return value upon an error.
+*/
+package tls
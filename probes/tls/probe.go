@@ -0,0 +1,194 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/cybozu-go/goma"
+	"github.com/cybozu-go/goma/probes"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	defaultErrval = -1.0
+)
+
+var (
+	errNoCertificate = errors.New("no certificate presented")
+	errNoCACert      = errors.New("no CA certificate found")
+)
+
+type probe struct {
+	address    string
+	serverName string
+	starttls   string
+	verify     bool
+	roots      *x509.CertPool
+	errval     float64
+}
+
+func (p *probe) Probe(ctx context.Context) float64 {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return p.fail(err)
+	}
+	defer conn.Close()
+
+	// interrupt I/O when ctx is done.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if len(p.starttls) > 0 {
+		if err := starttlsFuncs[p.starttls](conn); err != nil {
+			return p.fail(err)
+		}
+	}
+
+	// certificates are verified by p.verifyChain as expired
+	// certificates need to be inspected.
+	tc := tls.Client(conn, &tls.Config{
+		ServerName:         p.serverName,
+		InsecureSkipVerify: true,
+	})
+	if err := tc.Handshake(); err != nil {
+		return p.fail(err)
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return p.fail(errNoCertificate)
+	}
+
+	if p.verify {
+		if err := p.verifyChain(certs); err != nil {
+			return p.fail(err)
+		}
+	}
+
+	notAfter := certs[0].NotAfter
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return time.Until(notAfter).Hours() / 24
+}
+
+func (p *probe) verifyChain(certs []*x509.Certificate) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       p.serverName,
+		Roots:         p.roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func (p *probe) fail(err error) float64 {
+	log.Error("probe:tls error", map[string]interface{}{
+		"address": p.address,
+		"error":   err.Error(),
+	})
+	return p.errval
+}
+
+func (p *probe) String() string {
+	return "probe:tls:" + p.address
+}
+
+func construct(params map[string]interface{}) (probes.Prober, error) {
+	address, err := goma.GetString("address", params)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	serverName, err := goma.GetString("server_name", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		serverName = host
+	default:
+		return nil, err
+	}
+
+	starttls, err := goma.GetString("starttls", params)
+	switch err {
+	case nil:
+		if _, ok := starttlsFuncs[starttls]; !ok {
+			return nil, fmt.Errorf("unsupported starttls: %s", starttls)
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	verify, err := goma.GetBool("verify", params)
+	if err != nil && err != goma.ErrNoKey {
+		return nil, err
+	}
+
+	var roots *x509.CertPool
+	caFile, err := goma.GetString("ca_file", params)
+	switch err {
+	case nil:
+		if !verify {
+			return nil, errors.New("ca_file requires verify")
+		}
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: %v", caFile, errNoCACert)
+		}
+	case goma.ErrNoKey:
+	default:
+		return nil, err
+	}
+
+	errval, err := goma.GetFloat("errval", params)
+	switch err {
+	case nil:
+	case goma.ErrNoKey:
+		errval = defaultErrval
+	default:
+		return nil, err
+	}
+
+	return &probe{
+		address:    address,
+		serverName: serverName,
+		starttls:   starttls,
+		verify:     verify,
+		roots:      roots,
+		errval:     errval,
+	}, nil
+}
+
+func init() {
+	probes.Register("tls", construct)
+}
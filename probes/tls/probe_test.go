@@ -0,0 +1,273 @@
+package tls
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma"
+)
+
+const (
+	testDays = 10
+)
+
+var (
+	testCAPEM []byte
+	testCert  tls.Certificate
+
+	// addresses of test servers keyed by starttls protocol.
+	testAddresses = make(map[string]string)
+)
+
+// makeCerts creates a CA certificate and a server certificate that
+// expires in testDays days.
+func makeCerts() error {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goma test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+	testCAPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(0, 0, testDays),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	testCert = tls.Certificate{
+		Certificate: [][]byte{der, caDER},
+		PrivateKey:  key,
+	}
+	return nil
+}
+
+func smtpServer(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	io.WriteString(conn, "220 localhost ESMTP\r\n")
+	if _, err := r.ReadString('\n'); err != nil {
+		return err
+	}
+	io.WriteString(conn, "250-localhost\r\n250 STARTTLS\r\n")
+	if _, err := r.ReadString('\n'); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "220 ready to start TLS\r\n")
+	return err
+}
+
+func imapServer(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+	if _, err := r.ReadString('\n'); err != nil {
+		return err
+	}
+	_, err := io.WriteString(conn, "* NOTE untagged\r\na1 OK begin TLS\r\n")
+	return err
+}
+
+func postgresServer(conn net.Conn) error {
+	var req [8]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return err
+	}
+	_, err := conn.Write([]byte{'S'})
+	return err
+}
+
+func serve(l net.Listener, starttls func(net.Conn) error) {
+	config := &tls.Config{Certificates: []tls.Certificate{testCert}}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(c net.Conn) {
+			defer c.Close()
+			if starttls != nil {
+				if err := starttls(c); err != nil {
+					return
+				}
+			}
+			tc := tls.Server(c, config)
+			tc.Handshake()
+			io.Copy(io.Discard, tc)
+		}(conn)
+	}
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	if err := makeCerts(); err != nil {
+		log.Fatal(err)
+	}
+
+	servers := map[string]func(net.Conn) error{
+		"":         nil,
+		"smtp":     smtpServer,
+		"imap":     imapServer,
+		"postgres": postgresServer,
+	}
+	for proto, f := range servers {
+		l, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, port, _ := net.SplitHostPort(l.Addr().String())
+		testAddresses[proto] = net.JoinHostPort("localhost", port)
+		go serve(l, f)
+	}
+	os.Exit(m.Run())
+}
+
+func testProbe(t *testing.T, params map[string]interface{}) float64 {
+	t.Helper()
+
+	p, err := construct(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return p.Probe(ctx)
+}
+
+func testCAFile(t *testing.T) string {
+	t.Helper()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, testCAPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return caFile
+}
+
+func isTestDays(f float64) bool {
+	return f > testDays-1 && f <= testDays
+}
+
+func TestConstruct(t *testing.T) {
+	t.Parallel()
+
+	if _, err := construct(nil); err != goma.ErrNoKey {
+		t.Error(`err != goma.ErrNoKey`)
+	}
+	if _, err := construct(map[string]interface{}{
+		"address":  testAddresses[""],
+		"starttls": "pop3",
+	}); err == nil {
+		t.Error("unsupported starttls should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddresses[""],
+		"verify":  true,
+		"ca_file": filepath.Join(t.TempDir(), "none.pem"),
+	}); err == nil {
+		t.Error("missing ca_file should be rejected")
+	}
+	if _, err := construct(map[string]interface{}{
+		"address": testAddresses[""],
+		"ca_file": testCAFile(t),
+	}); err == nil {
+		t.Error("ca_file without verify should be rejected")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	t.Parallel()
+
+	f := testProbe(t, map[string]interface{}{
+		"address": testAddresses[""],
+	})
+	if !isTestDays(f) {
+		t.Error(`unexpected days:`, f)
+	}
+
+	for _, proto := range []string{"smtp", "imap", "postgres"} {
+		f := testProbe(t, map[string]interface{}{
+			"address":  testAddresses[proto],
+			"starttls": proto,
+		})
+		if !isTestDays(f) {
+			t.Error(proto, `unexpected days:`, f)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	f := testProbe(t, map[string]interface{}{
+		"address": testAddresses[""],
+		"verify":  true,
+	})
+	if !goma.FloatEquals(f, -1) {
+		t.Error("certificate from unknown CA should be rejected")
+	}
+
+	caFile := testCAFile(t)
+	f = testProbe(t, map[string]interface{}{
+		"address": testAddresses[""],
+		"verify":  true,
+		"ca_file": caFile,
+	})
+	if !isTestDays(f) {
+		t.Error(`unexpected days:`, f)
+	}
+
+	f = testProbe(t, map[string]interface{}{
+		"address":     testAddresses[""],
+		"server_name": "www.example.com",
+		"verify":      true,
+		"ca_file":     caFile,
+		"errval":      100.0,
+	})
+	if !goma.FloatEquals(f, 100.0) {
+		t.Error("host name mismatch should be rejected")
+	}
+}
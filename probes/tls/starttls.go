@@ -0,0 +1,89 @@
+package tls
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	// postgresSSLRequest is the request code of SSLRequest message.
+	postgresSSLRequest = 80877103
+)
+
+var starttlsFuncs = map[string]func(net.Conn) error{
+	"smtp":     starttlsSMTP,
+	"imap":     starttlsIMAP,
+	"postgres": starttlsPostgres,
+}
+
+// starttlsSMTP implements RFC 3207.
+func starttlsSMTP(conn net.Conn) error {
+	tc := textproto.NewConn(conn)
+	if _, _, err := tc.ReadResponse(220); err != nil {
+		return err
+	}
+	if err := tc.PrintfLine("EHLO goma"); err != nil {
+		return err
+	}
+	if _, _, err := tc.ReadResponse(250); err != nil {
+		return err
+	}
+	if err := tc.PrintfLine("STARTTLS"); err != nil {
+		return err
+	}
+	_, _, err := tc.ReadResponse(220)
+	return err
+}
+
+// starttlsIMAP implements STARTTLS command of RFC 3501.
+func starttlsIMAP(conn net.Conn) error {
+	tc := textproto.NewConn(conn)
+	greeting, err := tc.ReadLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected IMAP greeting: %s", greeting)
+	}
+	if err := tc.PrintfLine("a1 STARTTLS"); err != nil {
+		return err
+	}
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "a1 ") {
+			// untagged responses
+			continue
+		}
+		if strings.HasPrefix(line, "a1 OK") {
+			return nil
+		}
+		return fmt.Errorf("STARTTLS failed: %s", line)
+	}
+}
+
+// starttlsPostgres sends SSLRequest message of PostgreSQL protocol.
+func starttlsPostgres(conn net.Conn) error {
+	var req [8]byte
+	binary.BigEndian.PutUint32(req[0:], 8)
+	binary.BigEndian.PutUint32(req[4:], postgresSSLRequest)
+	if _, err := conn.Write(req[:]); err != nil {
+		return err
+	}
+
+	var resp [1]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return err
+	}
+	if resp[0] != 'S' {
+		return errors.New("server does not support SSL")
+	}
+	return nil
+}
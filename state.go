@@ -0,0 +1,195 @@
+package goma
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cybozu-go/goma/monitor"
+	"github.com/cybozu-go/log"
+)
+
+const (
+	stateFile = "monitors.json"
+)
+
+// savedMonitor is the persisted form of a dynamically registered monitor.
+type savedMonitor struct {
+	ID         int                `json:"id"`
+	Running    bool               `json:"running"`
+	Definition *MonitorDefinition `json:"definition"`
+}
+
+var (
+	stateLock sync.Mutex
+	stateDir  string
+
+	// monitors registered via REST API.
+	dynamicMonitors = make(map[int]*MonitorDefinition)
+)
+
+// SetStateDir sets the directory to save monitors registered via REST API.
+// If dir is empty, monitors are not saved.
+// The directory is created if it does not exist.
+func SetStateDir(dir string) error {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	if len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	stateDir = dir
+	return nil
+}
+
+// LoadState registers and starts monitors saved in the state directory.
+// Saved monitor IDs are kept.
+//
+// This should be called before any other monitors are registered.
+func LoadState() error {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	if len(stateDir) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, stateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var saved []*savedMonitor
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%s: %v", stateFile, err)
+	}
+
+	for _, sm := range saved {
+		m, err := CreateMonitor(sm.Definition)
+		if err != nil {
+			return err
+		}
+		if err := monitor.RegisterWithID(m, sm.ID); err != nil {
+			return fmt.Errorf("%s: %v", sm.Definition.Name, err)
+		}
+		dynamicMonitors[sm.ID] = sm.Definition
+		log.Info("restored monitor", map[string]interface{}{
+			"monitor_id": m.ID(),
+			"name":       m.Name(),
+		})
+		watchDynamicMonitor(m)
+		if sm.Running {
+			m.Start()
+		}
+	}
+	return nil
+}
+
+// watchDynamicMonitor saves the state when m stops by itself.
+// This must be called before m starts.
+func watchDynamicMonitor(m *monitor.Monitor) {
+	m.SetStopHandler(func() {
+		updateDynamicMonitor(m.ID())
+	})
+}
+
+// addDynamicMonitor records a monitor registered via REST API.
+func addDynamicMonitor(id int, d *MonitorDefinition) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	dynamicMonitors[id] = d
+	saveState()
+}
+
+// removeDynamicMonitor forgets a monitor registered via REST API.
+func removeDynamicMonitor(id int) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	if _, ok := dynamicMonitors[id]; !ok {
+		return
+	}
+	delete(dynamicMonitors, id)
+	saveState()
+}
+
+// updateDynamicMonitor saves the running state of a monitor
+// if it was registered via REST API.
+func updateDynamicMonitor(id int) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	if _, ok := dynamicMonitors[id]; !ok {
+		return
+	}
+	saveState()
+}
+
+// saveState writes dynamic monitors to the state directory.
+// The caller must hold stateLock.
+func saveState() {
+	if len(stateDir) == 0 {
+		return
+	}
+
+	saved := make([]*savedMonitor, 0, len(dynamicMonitors))
+	for id, d := range dynamicMonitors {
+		m := monitor.FindMonitor(id)
+		if m == nil {
+			continue
+		}
+		saved = append(saved, &savedMonitor{
+			ID:         id,
+			Running:    m.Running(),
+			Definition: d,
+		})
+	}
+	sort.Slice(saved, func(i, j int) bool {
+		return saved[i].ID < saved[j].ID
+	})
+
+	if err := writeState(saved); err != nil {
+		log.Error("failed to save monitors", map[string]interface{}{
+			"dir":   stateDir,
+			"error": err.Error(),
+		})
+	}
+}
+
+// writeState replaces the state file atomically.
+func writeState(saved []*savedMonitor) error {
+	data, err := json.MarshalIndent(saved, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(stateDir, "."+stateFile+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(stateDir, stateFile))
+}
@@ -0,0 +1,186 @@
+package goma
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybozu-go/goma/actions"
+	"github.com/cybozu-go/goma/monitor"
+	"github.com/cybozu-go/goma/probes"
+)
+
+func init() {
+	probes.Register("test", func(params map[string]interface{}) (probes.Prober, error) {
+		return &testProbe{}, nil
+	})
+	actions.Register("test", func(params map[string]interface{}) (actions.Actor, error) {
+		return &testActor{}, nil
+	})
+	actions.Register("block", func(params map[string]interface{}) (actions.Actor, error) {
+		return &blockActor{}, nil
+	})
+}
+
+// initResult is returned from blockActor.Init.
+var initResult = make(chan error)
+
+// blockActor waits for initResult in Init.
+type blockActor struct {
+	testActor
+}
+
+func (a *blockActor) Init(name string) error {
+	return <-initResult
+}
+
+const testDefinition = `{
+    "name": "dynamic1",
+    "probe": {"type": "test"},
+    "actions": [{"type": "test"}],
+    "max": 1
+}`
+
+func serveTest(method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	NewRouter().ServeHTTP(w, req)
+	return w
+}
+
+func readState(t *testing.T, dir string) []*savedMonitor {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []*savedMonitor
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+func TestStateStoppedByItself(t *testing.T) {
+	dir := t.TempDir()
+	if err := SetStateDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer SetStateDir("")
+
+	w := serveTest(http.MethodPost, "/register", "application/json", `{
+    "name": "dynamic2",
+    "probe": {"type": "test"},
+    "actions": [{"type": "block"}],
+    "max": 1
+}`)
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	id, err := strconv.Atoi(w.Body.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serveTest(http.MethodDelete, "/monitor/"+strconv.Itoa(id), "", "")
+
+	saved := readState(t, dir)
+	if len(saved) != 1 || !saved[0].Running {
+		t.Fatal("monitor should be saved as running")
+	}
+
+	// the monitor stops by itself as the action fails to initialize.
+	initResult <- errors.New("init failed")
+	deadline := time.Now().Add(5 * time.Second)
+	for readState(t, dir)[0].Running {
+		if time.Now().After(deadline) {
+			t.Fatal("monitor is not saved as stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestState(t *testing.T) {
+	// the state directory is created if not exist.
+	dir := filepath.Join(t.TempDir(), "state")
+	if err := SetStateDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer SetStateDir("")
+
+	w := serveTest(http.MethodPost, "/register", "application/json", testDefinition)
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	id, err := strconv.Atoi(w.Body.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w = serveTest(http.MethodPost, "/monitor/"+strconv.Itoa(id), "text/plain", "stop")
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved []*savedMonitor
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatal(`len(saved) != 1`)
+	}
+	if saved[0].ID != id {
+		t.Error(`saved[0].ID != id`)
+	}
+	if saved[0].Running {
+		t.Error(`saved[0].Running`)
+	}
+	if saved[0].Definition.Name != "dynamic1" {
+		t.Error(`saved[0].Definition.Name != "dynamic1"`)
+	}
+
+	// simulate restart.
+	monitor.Unregister(monitor.FindMonitor(id))
+	delete(dynamicMonitors, id)
+
+	if err := LoadState(); err != nil {
+		t.Fatal(err)
+	}
+	m := monitor.FindMonitor(id)
+	if m == nil {
+		t.Fatal("monitor is not restored")
+	}
+	if m.Name() != "dynamic1" {
+		t.Error(`m.Name() != "dynamic1"`)
+	}
+	if m.Running() {
+		t.Error(`m.Running()`)
+	}
+
+	w = serveTest(http.MethodDelete, "/monitor/"+strconv.Itoa(id), "", "")
+	if w.Code != http.StatusOK {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	data, err = os.ReadFile(filepath.Join(dir, stateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Error(`len(saved) != 0`)
+	}
+}
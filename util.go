@@ -31,16 +31,27 @@ func GetBool(key string, m map[string]interface{}) (bool, error) {
 
 // GetInt extracts an integer from TOML decoded map.
 // If m[key] does not exist or is not an integer, non-nil error is returned.
+//
+// As JSON numbers are decoded into float64, a float without
+// fractional part is also accepted.
 func GetInt(key string, m map[string]interface{}) (int, error) {
 	v, ok := m[key]
 	if !ok {
 		return 0, ErrNoKey
 	}
-	i, ok := v.(int)
-	if !ok {
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, ErrInvalidType
+		}
+		return int(v), nil
+	default:
 		return 0, ErrInvalidType
 	}
-	return i, nil
 }
 
 // GetFloat extracts a float from TOML decoded map.
@@ -55,6 +66,8 @@ func GetFloat(key string, m map[string]interface{}) (float64, error) {
 		return v, nil
 	case int:
 		return float64(v), nil
+	case int64:
+		return float64(v), nil
 	default:
 		return 0, ErrInvalidType
 	}
@@ -101,6 +114,33 @@ func GetStringList(key string, m map[string]interface{}) ([]string, error) {
 	return ret, nil
 }
 
+// GetIntList constructs an integer list from TOML decoded map.
+// If m[key] does not exist or is not an integer list, non-nil error is returned.
+func GetIntList(key string, m map[string]interface{}) ([]int, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, ErrNoKey
+	}
+
+	if il, ok := v.([]int); ok {
+		return il, nil
+	}
+
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	ret := make([]int, 0, len(l))
+	for _, t := range l {
+		i, err := GetInt("", map[string]interface{}{"": t})
+		if err != nil {
+			return nil, ErrInvalidType
+		}
+		ret = append(ret, i)
+	}
+	return ret, nil
+}
+
 // GetStringMap constructs a map[string]string from TOML decoded map.
 // If m[key] does not exist or is not a string map, non-nil error is returned.
 func GetStringMap(key string, m map[string]interface{}) (map[string]string, error) {
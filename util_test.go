@@ -0,0 +1,87 @@
+package goma
+
+import "testing"
+
+func TestGetInt(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]interface{}{
+		"int":   10,
+		"int64": int64(20),
+		"json":  30.0,
+		"float": 1.5,
+		"str":   "40",
+	}
+
+	if _, err := GetInt("none", m); err != ErrNoKey {
+		t.Error(`err != ErrNoKey`)
+	}
+	for k, expected := range map[string]int{"int": 10, "int64": 20, "json": 30} {
+		i, err := GetInt(k, m)
+		if err != nil {
+			t.Error(k, err)
+			continue
+		}
+		if i != expected {
+			t.Error(k, i, "!=", expected)
+		}
+	}
+	for _, k := range []string{"float", "str"} {
+		if _, err := GetInt(k, m); err != ErrInvalidType {
+			t.Error(k, `err != ErrInvalidType`)
+		}
+	}
+}
+
+func TestGetFloat(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]interface{}{
+		"int":   10,
+		"int64": int64(20),
+		"float": 1.5,
+		"str":   "40",
+	}
+
+	for k, expected := range map[string]float64{"int": 10, "int64": 20, "float": 1.5} {
+		f, err := GetFloat(k, m)
+		if err != nil {
+			t.Error(k, err)
+			continue
+		}
+		if !FloatEquals(f, expected) {
+			t.Error(k, f, "!=", expected)
+		}
+	}
+	if _, err := GetFloat("str", m); err != ErrInvalidType {
+		t.Error(`err != ErrInvalidType`)
+	}
+}
+
+func TestGetIntList(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]interface{}{
+		"ints":  []int{1, 2},
+		"toml":  []interface{}{int64(200), int64(204)},
+		"mixed": []interface{}{int64(200), "204"},
+	}
+
+	l, err := GetIntList("ints", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 || l[0] != 1 || l[1] != 2 {
+		t.Error(`unexpected list:`, l)
+	}
+	l, err = GetIntList("toml", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 || l[0] != 200 || l[1] != 204 {
+		t.Error(`unexpected list:`, l)
+	}
+	if _, err := GetIntList("mixed", m); err != ErrInvalidType {
+		t.Error(`err != ErrInvalidType`)
+	}
+}